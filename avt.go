@@ -0,0 +1,193 @@
+/*
+ * Copyright 2018 Johannes Donath <johannesd@torchmind.com>
+ * and other copyright owners as documented in the project's IP log.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+package sstv
+
+import (
+  "github.com/go-audio/audio"
+  "image"
+  "io"
+)
+
+type AVTMode uint8
+
+const (
+  AVT24  AVTMode = 64
+  AVT90  AVTMode = 68
+  AVT94  AVTMode = 72
+  AVT125 AVTMode = 84
+  AVT188 AVTMode = 76
+)
+
+const (
+  avt24PulseLength  = .48828
+  avt90PulseLength  = .48828
+  avt94PulseLength  = .48958
+  avt125PulseLength = .97656
+  avt188PulseLength = .48958
+)
+
+// provides an AVT implementation as designed for the Amiga Video Transceiver
+//
+// this implementation encodes RGB images (or grayscale images when using AVT125) without any line
+// synchronization pulses; instead, receivers synchronize using the digital header which precedes
+// the image
+type avtEncoder struct {
+  mode   AVTMode
+  format *audio.Format
+}
+
+// creates a new AVT compatible image encoder
+func NewAVT(mode AVTMode, format *audio.Format) (Encoder, error) {
+  switch mode {
+  case AVT24, AVT90, AVT94, AVT125, AVT188:
+  default:
+    return nil, ErrIllegalMode
+  }
+
+  if err := validateFormat(format, whiteFrequency); err != nil {
+    return nil, err
+  }
+
+  return &avtEncoder{
+    mode:   mode,
+    format: format,
+  }, nil
+}
+
+func (enc *avtEncoder) Vis() uint8 {
+  return uint8(enc.mode)
+}
+
+func (enc *avtEncoder) Resolution() image.Rectangle {
+  switch enc.mode {
+  case AVT24:
+    return image.Rect(0, 0, 128, 128)
+  case AVT90:
+    return image.Rect(0, 0, 256, 240)
+  case AVT94:
+    return image.Rect(0, 0, 320, 200)
+  default:
+    return image.Rect(0, 0, 320, 400)
+  }
+}
+
+func (enc *avtEncoder) Info() ModeInfo {
+  res := enc.Resolution()
+
+  var name string
+  switch enc.mode {
+  case AVT24:
+    name = "AVT 24"
+  case AVT90:
+    name = "AVT 90"
+  case AVT94:
+    name = "AVT 94"
+  case AVT125:
+    name = "AVT 125"
+  default:
+    name = "AVT 188"
+  }
+
+  model := ColorRGB
+  order := []Channel{ChannelRed, ChannelGreen, ChannelBlue}
+  if enc.mode == AVT125 {
+    model = ColorGray
+    order = []Channel{ChannelY}
+  }
+
+  return newModeInfo(
+    name,
+    model,
+    order,
+    blackFrequency,
+    whiteFrequency,
+    tonesLength(calibrationHeader)+visLength+avtHeaderLength,
+    float64(len(order)*res.Dx())*enc.pulseLength(),
+    res.Dy(),
+  )
+}
+
+// retrieves the length of a single pixel within this mode
+func (enc *avtEncoder) pulseLength() float64 {
+  switch enc.mode {
+  case AVT24:
+    return avt24PulseLength
+  case AVT90:
+    return avt90PulseLength
+  case AVT94:
+    return avt94PulseLength
+  case AVT125:
+    return avt125PulseLength
+  case AVT188:
+    return avt188PulseLength
+  default:
+    return 0
+  }
+}
+
+func (enc *avtEncoder) Encode(img image.Image) *audio.FloatBuffer {
+  buf, _ := encodeBuffer(enc, img, EncodeOptions{})
+  return buf
+}
+
+func (enc *avtEncoder) EncodeTo(img image.Image, w io.Writer) error {
+  return encodeStream(enc, img, w, EncodeOptions{})
+}
+
+func (enc *avtEncoder) audioFormat() *audio.Format {
+  return enc.format
+}
+
+func (enc *avtEncoder) encode(wr *audioWriter, img image.Image) {
+  pulseLength := enc.pulseLength()
+
+  wr.writeHeader()
+  wr.writeVis(uint8(enc.mode))
+  wr.writeAVTHeader(uint8(enc.mode))
+
+  size := img.Bounds().Size()
+  for y := 0; y < size.Y; y++ {
+    if enc.mode == AVT125 {
+      for x := 0; x < size.X; x++ {
+        y, _, _ := convertYUV(img.At(x, y))
+        wr.writeValue(float64(y)/255, pulseLength)
+      }
+
+      wr.endLine(y, size.Y)
+      continue
+    }
+
+    for i := 0; i < 3; i++ {
+      for x := 0; x < size.X; x++ {
+        r, g, b := convertRGB(img.At(x, y))
+
+        var val float64
+        switch i {
+        case 0:
+          val = r
+        case 1:
+          val = g
+        case 2:
+          val = b
+        }
+        wr.writeValue(val, pulseLength)
+      }
+    }
+
+    wr.endLine(y, size.Y)
+  }
+}
@@ -0,0 +1,281 @@
+/*
+ * Copyright 2018 Johannes Donath <johannesd@torchmind.com>
+ * and other copyright owners as documented in the project's IP log.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+package sstv
+
+import (
+  "github.com/go-audio/audio"
+  "image"
+  "io"
+  "sync"
+)
+
+// describes how a signal is distributed among the channels of a multi channel audio format
+type ChannelLayout int
+
+const (
+  // duplicates the signal onto every channel
+  ChannelDuplicate ChannelLayout = iota
+  // places the signal on a single channel while all other channels remain silent
+  ChannelSingle
+)
+
+// represents an individual transmission within a multi channel signal
+type Transmission struct {
+  Encoder Encoder
+  Image   image.Image
+}
+
+// interleaves a single channel signal into the frames of a multi channel signal
+type channelSink struct {
+  out      sampleSink
+  channels int
+  layout   ChannelLayout
+  channel  int
+  scratch  []float64
+}
+
+// creates a new sink which distributes samples among the given number of channels using the
+// given layout
+func newChannelSink(out sampleSink, channels int, layout ChannelLayout, channel int) *channelSink {
+  return &channelSink{
+    out:      out,
+    channels: channels,
+    layout:   layout,
+    channel:  channel,
+  }
+}
+
+func (s *channelSink) writeSamples(samples []float64) error {
+  if cap(s.scratch) < len(samples)*s.channels {
+    s.scratch = make([]float64, len(samples)*s.channels)
+  }
+  s.scratch = s.scratch[:len(samples)*s.channels]
+
+  for i, sample := range samples {
+    frame := s.scratch[i*s.channels : (i+1)*s.channels]
+    for c := range frame {
+      if s.layout == ChannelDuplicate || c == s.channel {
+        frame[c] = sample
+      } else {
+        frame[c] = 0
+      }
+    }
+  }
+
+  return s.out.writeSamples(s.scratch)
+}
+
+func (s *channelSink) flush() error {
+  return s.out.flush()
+}
+
+// passes the samples of an individual transmission to the goroutine which combines all
+// transmissions of a multi channel signal
+type muxSink struct {
+  ch   chan<- []float64
+  done <-chan struct{}
+}
+
+func (s *muxSink) writeSamples(samples []float64) error {
+  if len(samples) == 0 {
+    return nil
+  }
+
+  // writers may reuse their buffers once the samples have been passed on
+  chunk := make([]float64, len(samples))
+  copy(chunk, samples)
+
+  select {
+  case s.ch <- chunk:
+    return nil
+  case <-s.done:
+    return io.ErrClosedPipe
+  }
+}
+
+func (s *muxSink) flush() error {
+  return nil
+}
+
+// encodes a set of independent transmissions into a multi channel signal where each channel
+// carries its respective transmission (such as two different images on the left and right channel
+// of a stereo signal)
+//
+// all encoders must share a common sample rate and the resulting signal consists of one channel
+// per transmission; shorter transmissions are followed by silence. The channel layout of the
+// options is ignored and progress is only reported for the first transmission
+func EncodeChannels(transmissions []Transmission, opts EncodeOptions) (*audio.FloatBuffer, error) {
+  format, err := channelsFormat(transmissions)
+  if err != nil {
+    return nil, err
+  }
+  if err := opts.validate(format); err != nil {
+    return nil, err
+  }
+
+  out := opts.newBufferSink(format)
+  if err := encodeChannels(transmissions, format, out, opts); err != nil {
+    return nil, err
+  }
+  return out.buf, nil
+}
+
+// encodes a set of independent transmissions into a multi channel signal and writes it onto the
+// given stream as raw interleaved little endian PCM samples in the selected sample format
+//
+// as with EncodeChannels, each channel carries its respective transmission while the
+// transmissions are generated concurrently in order to keep memory usage constant
+func EncodeChannelsTo(transmissions []Transmission, w io.Writer, opts EncodeOptions) error {
+  format, err := channelsFormat(transmissions)
+  if err != nil {
+    return err
+  }
+  if err := opts.validate(format); err != nil {
+    return err
+  }
+
+  return encodeChannels(transmissions, format, newStreamSink(w, opts.SampleFormat), opts)
+}
+
+// computes the format of a multi channel signal which carries the given transmissions
+func channelsFormat(transmissions []Transmission) (*audio.Format, error) {
+  if len(transmissions) == 0 {
+    return nil, ErrNoTransmissions
+  }
+
+  var sampleRate int
+  for i, t := range transmissions {
+    mode, ok := t.Encoder.(modeEncoder)
+    if !ok {
+      return nil, ErrUnsupportedEncoder
+    }
+
+    if i == 0 {
+      sampleRate = mode.audioFormat().SampleRate
+    } else if mode.audioFormat().SampleRate != sampleRate {
+      return nil, ErrSampleRateMismatch
+    }
+  }
+
+  return &audio.Format{
+    NumChannels: len(transmissions),
+    SampleRate:  sampleRate,
+  }, nil
+}
+
+// generates all transmissions concurrently and passes their interleaved samples to the given sink
+func encodeChannels(transmissions []Transmission, format *audio.Format, out sampleSink, opts EncodeOptions) error {
+  mono := &audio.Format{
+    NumChannels: 1,
+    SampleRate:  format.SampleRate,
+  }
+
+  done := make(chan struct{})
+  chans := make([]chan []float64, len(transmissions))
+  errs := make([]error, len(transmissions))
+  var wg sync.WaitGroup
+
+  for i, t := range transmissions {
+    chans[i] = make(chan []float64, 1)
+
+    o := opts
+    if i != 0 {
+      o.Progress = nil
+    }
+
+    wg.Add(1)
+    go func(i int, enc modeEncoder, img image.Image) {
+      defer wg.Done()
+      defer close(chans[i])
+
+      wr := newWriter(mono, &muxSink{chans[i], done}, o)
+      enc.encode(wr, img)
+      errs[i] = wr.close()
+    }(i, t.Encoder.(modeEncoder), t.Image)
+  }
+
+  err := interleave(chans, out)
+  close(done)
+  wg.Wait()
+
+  // errors within the individual transmissions take precedence as they typically cause the
+  // output to be aborted
+  for _, e := range errs {
+    if e != nil && e != io.ErrClosedPipe {
+      return e
+    }
+  }
+  if err != nil {
+    return err
+  }
+  return out.flush()
+}
+
+// combines the samples which are received on the given channels into interleaved frames until all
+// channels have been closed
+func interleave(chans []chan []float64, out sampleSink) error {
+  pending := make([][]float64, len(chans))
+  closed := make([]bool, len(chans))
+
+  for {
+    // wait until every open channel has at least one sample available
+    for i, ch := range chans {
+      for !closed[i] && len(pending[i]) == 0 {
+        chunk, ok := <-ch
+        if !ok {
+          closed[i] = true
+        }
+        pending[i] = chunk
+      }
+    }
+
+    // frames may only be emitted as long as all open channels have samples available while
+    // closed channels are padded with silence
+    n := -1
+    remaining := 0
+    for i := range chans {
+      if !closed[i] && (n == -1 || len(pending[i]) < n) {
+        n = len(pending[i])
+      }
+      if len(pending[i]) > remaining {
+        remaining = len(pending[i])
+      }
+    }
+    if n == -1 {
+      n = remaining
+    }
+    if n == 0 {
+      return nil
+    }
+
+    frames := make([]float64, n*len(chans))
+    for i := range chans {
+      for j := 0; j < n && j < len(pending[i]); j++ {
+        frames[j*len(chans)+i] = pending[i][j]
+      }
+      if n < len(pending[i]) {
+        pending[i] = pending[i][n:]
+      } else {
+        pending[i] = nil
+      }
+    }
+
+    if err := out.writeSamples(frames); err != nil {
+      return err
+    }
+  }
+}
@@ -0,0 +1,175 @@
+/*
+ * Copyright 2018 Johannes Donath <johannesd@torchmind.com>
+ * and other copyright owners as documented in the project's IP log.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+package sstv
+
+import (
+  "context"
+  "errors"
+  "github.com/go-audio/audio"
+  "image"
+  "io/ioutil"
+  "testing"
+  "time"
+)
+
+// fails after accepting a fixed number of writes
+type failingWriter struct {
+  writes int
+}
+
+var errWriter = errors.New("write failed")
+
+func (w *failingWriter) Write(p []byte) (int, error) {
+  if w.writes == 0 {
+    return 0, errWriter
+  }
+  w.writes--
+  return len(p), nil
+}
+
+// creates a pair of transmissions of unequal length
+func unequalTransmissions(t *testing.T) []Transmission {
+  format := &audio.Format{NumChannels: 1, SampleRate: 11025}
+
+  short, err := NewRobot(Robot8BW, format)
+  if err != nil {
+    t.Fatal(err)
+  }
+  long, err := NewRobot(Robot12BW, format)
+  if err != nil {
+    t.Fatal(err)
+  }
+
+  return []Transmission{
+    {short, image.NewRGBA(short.Resolution())},
+    {long, image.NewRGBA(long.Resolution())},
+  }
+}
+
+// fails the test when the given function does not return in time
+func withTimeout(t *testing.T, fn func()) {
+  done := make(chan struct{})
+  go func() {
+    defer close(done)
+    fn()
+  }()
+
+  select {
+  case <-done:
+  case <-time.After(30 * time.Second):
+    t.Fatal("encoding process did not terminate")
+  }
+}
+
+func TestEncodeChannelsInterleave(t *testing.T) {
+  transmissions := unequalTransmissions(t)
+  opts := EncodeOptions{Normalize: true}
+
+  left, err := EncodeWithOptions(transmissions[0].Encoder, transmissions[0].Image, opts)
+  if err != nil {
+    t.Fatal(err)
+  }
+  right, err := EncodeWithOptions(transmissions[1].Encoder, transmissions[1].Image, opts)
+  if err != nil {
+    t.Fatal(err)
+  }
+
+  buf, err := EncodeChannels(transmissions, opts)
+  if err != nil {
+    t.Fatal(err)
+  }
+
+  if buf.Format.NumChannels != 2 {
+    t.Fatalf("encoded %d channels (expected 2)", buf.Format.NumChannels)
+  }
+  if len(buf.Data) != 2*len(right.Data) {
+    t.Fatalf("encoded %d samples (expected %d)", len(buf.Data), 2*len(right.Data))
+  }
+
+  // the shorter transmission is followed by silence
+  for i := range right.Data {
+    expected := 0.0
+    if i < len(left.Data) {
+      expected = left.Data[i]
+    }
+
+    if buf.Data[2*i] != expected || buf.Data[2*i+1] != right.Data[i] {
+      t.Fatalf("frame %d does not match its transmissions", i)
+    }
+  }
+}
+
+func TestEncodeChannelsCancel(t *testing.T) {
+  transmissions := unequalTransmissions(t)
+
+  ctx, cancel := context.WithCancel(context.Background())
+  opts := EncodeOptions{
+    Context: ctx,
+    Progress: func(line int, lines int, elapsed time.Duration) {
+      if line == 10 {
+        cancel()
+      }
+    },
+  }
+
+  withTimeout(t, func() {
+    if err := EncodeChannelsTo(transmissions, ioutil.Discard, opts); err != context.Canceled {
+      t.Errorf("unexpected error: %v", err)
+    }
+  })
+}
+
+func TestEncodeChannelsWriteError(t *testing.T) {
+  transmissions := unequalTransmissions(t)
+
+  withTimeout(t, func() {
+    if err := EncodeChannelsTo(transmissions, &failingWriter{writes: 2}, EncodeOptions{}); err != errWriter {
+      t.Errorf("unexpected error: %v", err)
+    }
+  })
+}
+
+func TestChannelLayout(t *testing.T) {
+  stereo := &audio.Format{NumChannels: 2, SampleRate: 11025}
+  enc, err := NewRobot(Robot8BW, stereo)
+  if err != nil {
+    t.Fatal(err)
+  }
+  img := image.NewRGBA(enc.Resolution())
+
+  duplicate, err := EncodeWithOptions(enc, img, EncodeOptions{})
+  if err != nil {
+    t.Fatal(err)
+  }
+  single, err := EncodeWithOptions(enc, img, EncodeOptions{ChannelLayout: ChannelSingle, OutputChannel: 1})
+  if err != nil {
+    t.Fatal(err)
+  }
+
+  for i := 0; i < len(duplicate.Data); i += 2 {
+    if duplicate.Data[i] != duplicate.Data[i+1] {
+      t.Fatalf("frame %d differs between channels", i/2)
+    }
+    if single.Data[i] != 0 || single.Data[i+1] != duplicate.Data[i] {
+      t.Fatalf("frame %d is not placed on the selected channel", i/2)
+    }
+  }
+
+  if _, err := EncodeWithOptions(enc, img, EncodeOptions{ChannelLayout: ChannelSingle, OutputChannel: 2}); err != ErrOutputChannel {
+    t.Errorf("unexpected error: %v", err)
+  }
+}
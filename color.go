@@ -17,6 +17,7 @@
 package sstv
 
 import (
+  "image"
   "image/color"
 )
 
@@ -51,3 +52,26 @@ func clamp(input float64) float64 {
   }
   return input
 }
+
+// converts two vertically adjacent pixels into their respective luminance values as well as the
+// averaged chrominance values which are shared between both rows
+func convertYUVPair(top color.Color, bottom color.Color) (byte, byte, byte, byte) {
+  y0, u0, v0 := convertYUV(top)
+  y1, u1, v1 := convertYUV(bottom)
+
+  return y0, y1, byte((int(u0) + int(u1)) / 2), byte((int(v0) + int(v1)) / 2)
+}
+
+// converts a single image line into its luminance and chrominance values
+func convertYUVLine(img image.Image, y int) ([]byte, []byte, []byte) {
+  size := img.Bounds().Size()
+  yl := make([]byte, size.X)
+  ul := make([]byte, size.X)
+  vl := make([]byte, size.X)
+
+  for x := 0; x < size.X; x++ {
+    yl[x], ul[x], vl[x] = convertYUV(img.At(x, y))
+  }
+
+  return yl, ul, vl
+}
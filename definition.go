@@ -0,0 +1,407 @@
+/*
+ * Copyright 2018 Johannes Donath <johannesd@torchmind.com>
+ * and other copyright owners as documented in the project's IP log.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+package sstv
+
+import (
+  "encoding/json"
+  "fmt"
+  "github.com/go-audio/audio"
+  "image"
+  "io"
+  "os"
+)
+
+// identifies the type of a single step within a mode definition
+type StepType string
+
+const (
+  // emits a fixed frequency tone (such as a sync pulse, porch or separator)
+  StepTone StepType = "tone"
+  // emits one pulse per pixel for a single channel of an image line
+  StepScan StepType = "scan"
+)
+
+// identifies the image channel which is transmitted by a scan step
+type Channel string
+
+const (
+  ChannelRed   Channel = "r"
+  ChannelGreen Channel = "g"
+  ChannelBlue  Channel = "b"
+  // luminance (also used for grayscale modes)
+  ChannelY Channel = "y"
+  // B-Y chrominance
+  ChannelU Channel = "u"
+  // R-Y chrominance
+  ChannelV Channel = "v"
+)
+
+// describes a single step within the preamble or line sequence of a mode definition
+type ModeStep struct {
+  Type StepType `json:"type"`
+  // frequency (in Hz) of tone steps
+  Frequency float64 `json:"frequency,omitempty"`
+  // length (in milliseconds) of tone steps or of each individual pixel within scan steps
+  Length float64 `json:"length"`
+  // channel which is transmitted by scan steps
+  Channel Channel `json:"channel,omitempty"`
+  // line (relative to the first line of the current group) which is transmitted by scan steps
+  Row int `json:"row,omitempty"`
+}
+
+// provides a data driven description of an arbitrary SSTV mode
+//
+// each repetition of the line sequence consumes a group of image lines (typically one, or two for
+// modes which share their chrominance between lines such as PD); chrominance channels are averaged
+// over all lines within their group
+type ModeDefinition struct {
+  Name string `json:"name"`
+  // VIS code of this mode (regular codes are limited to 7 bits while codes beyond 8 bits are
+  // transmitted as extended 16 bit codes)
+  Vis    uint16 `json:"vis"`
+  Width  int    `json:"width"`
+  Height int    `json:"height"`
+  // frequency range (in Hz) used to encode pixel values (defaults to 1500 - 2300 Hz when neither
+  // frequency is given)
+  BlackFrequency float64 `json:"black_frequency,omitempty"`
+  WhiteFrequency float64 `json:"white_frequency,omitempty"`
+  // number of image lines which are consumed by each repetition of the line sequence (defaults to 1)
+  LinesPerSequence int `json:"lines_per_sequence,omitempty"`
+  // tones which replace the standard calibration header (if any)
+  Preamble []ModeStep `json:"preamble,omitempty"`
+  Sequence []ModeStep `json:"sequence"`
+}
+
+// loads a list of mode definitions from a JSON encoded stream
+func LoadModes(r io.Reader) ([]*ModeDefinition, error) {
+  var defs []*ModeDefinition
+  if err := json.NewDecoder(r).Decode(&defs); err != nil {
+    return nil, err
+  }
+
+  for _, def := range defs {
+    if err := def.validate(); err != nil {
+      return nil, err
+    }
+  }
+
+  return defs, nil
+}
+
+// loads a list of mode definitions from a JSON file
+func LoadModeFile(path string) ([]*ModeDefinition, error) {
+  f, err := os.Open(path)
+  if err != nil {
+    return nil, err
+  }
+  defer f.Close()
+
+  return LoadModes(f)
+}
+
+// verifies whether the definition describes a mode which can be encoded
+func (def *ModeDefinition) validate() error {
+  if def.Width <= 0 || def.Height <= 0 {
+    return fmt.Errorf("mode %q: illegal resolution %dx%d", def.Name, def.Width, def.Height)
+  }
+  if def.LinesPerSequence < 0 {
+    return fmt.Errorf("mode %q: illegal number of lines per sequence: %d", def.Name, def.LinesPerSequence)
+  }
+  if len(def.Sequence) == 0 {
+    return fmt.Errorf("mode %q: empty line sequence", def.Name)
+  }
+
+  // regular VIS codes consist of 7 data bits and a parity bit while all codes beyond 8 bits are
+  // transmitted as extended codes
+  if def.Vis >= 0x80 && def.Vis <= 0xff {
+    return fmt.Errorf("mode %q: illegal VIS code 0x%02x", def.Name, def.Vis)
+  }
+
+  if def.BlackFrequency != 0 || def.WhiteFrequency != 0 {
+    if !(def.BlackFrequency > 0) || !(def.WhiteFrequency > 0) {
+      return fmt.Errorf("mode %q: black and white frequencies must both be positive", def.Name)
+    }
+  }
+
+  for _, step := range def.Preamble {
+    if step.Type != StepTone {
+      return fmt.Errorf("mode %q: preamble may only consist of tones", def.Name)
+    }
+    if err := def.validateStep(step); err != nil {
+      return err
+    }
+  }
+
+  for _, step := range def.Sequence {
+    if err := def.validateStep(step); err != nil {
+      return err
+    }
+
+    switch step.Type {
+    case StepTone:
+    case StepScan:
+      switch step.Channel {
+      case ChannelRed, ChannelGreen, ChannelBlue, ChannelY, ChannelU, ChannelV:
+      default:
+        return fmt.Errorf("mode %q: illegal channel %q", def.Name, step.Channel)
+      }
+
+      if step.Row < 0 || step.Row >= def.lines() {
+        return fmt.Errorf("mode %q: illegal row %d", def.Name, step.Row)
+      }
+    default:
+      return fmt.Errorf("mode %q: illegal step type %q", def.Name, step.Type)
+    }
+  }
+
+  return nil
+}
+
+// verifies whether the timing and frequency of a single step are within their permitted ranges
+func (def *ModeDefinition) validateStep(step ModeStep) error {
+  if !(step.Length > 0) {
+    return fmt.Errorf("mode %q: illegal step length %v", def.Name, step.Length)
+  }
+  if step.Type == StepTone && !(step.Frequency > 0) {
+    return fmt.Errorf("mode %q: illegal step frequency %v", def.Name, step.Frequency)
+  }
+  return nil
+}
+
+// retrieves the number of lines which are consumed by each repetition of the line sequence
+func (def *ModeDefinition) lines() int {
+  if def.LinesPerSequence == 0 {
+    return 1
+  }
+  return def.LinesPerSequence
+}
+
+// retrieves the frequency range in which pixel values are encoded
+func (def *ModeDefinition) frequencyRange() (float64, float64) {
+  black, white := def.BlackFrequency, def.WhiteFrequency
+  if black == 0 && white == 0 {
+    return blackFrequency, whiteFrequency
+  }
+  return black, white
+}
+
+// retrieves the highest frequency (in Hz) which is transmitted within this mode
+func (def *ModeDefinition) maxFrequency() float64 {
+  _, frequency := def.frequencyRange()
+  if frequency < headerFrequency {
+    frequency = headerFrequency
+  }
+
+  for _, steps := range [][]ModeStep{def.Preamble, def.Sequence} {
+    for _, step := range steps {
+      if step.Type == StepTone && step.Frequency > frequency {
+        frequency = step.Frequency
+      }
+    }
+  }
+  return frequency
+}
+
+// provides a generic encoder which transmits images according to a mode definition
+type definitionEncoder struct {
+  def    *ModeDefinition
+  format *audio.Format
+}
+
+// provides a generic encoder for mode definitions which rely on extended VIS codes
+type extendedDefinitionEncoder struct {
+  definitionEncoder
+}
+
+// creates a new image encoder for an arbitrary mode definition
+func NewDefinitionEncoder(def *ModeDefinition, format *audio.Format) (Encoder, error) {
+  if def == nil {
+    return nil, ErrIllegalMode
+  }
+  if err := def.validate(); err != nil {
+    return nil, err
+  }
+  if err := validateFormat(format, def.maxFrequency()); err != nil {
+    return nil, err
+  }
+
+  enc := definitionEncoder{
+    def:    def,
+    format: format,
+  }
+
+  if def.Vis > 0xff {
+    return &extendedDefinitionEncoder{enc}, nil
+  }
+  return &enc, nil
+}
+
+func (enc *definitionEncoder) Vis() uint8 {
+  return uint8(enc.def.Vis)
+}
+
+func (enc *extendedDefinitionEncoder) ExtendedVis() uint16 {
+  return enc.def.Vis
+}
+
+func (enc *definitionEncoder) Resolution() image.Rectangle {
+  return image.Rect(0, 0, enc.def.Width, enc.def.Height)
+}
+
+func (enc *definitionEncoder) Info() ModeInfo {
+  model := ColorGray
+  var order []Channel
+  var lineLength float64
+  for _, step := range enc.def.Sequence {
+    if step.Type == StepTone {
+      lineLength += step.Length
+      continue
+    }
+
+    lineLength += float64(enc.def.Width) * step.Length
+    order = append(order, step.Channel)
+
+    switch step.Channel {
+    case ChannelRed, ChannelGreen, ChannelBlue:
+      model = ColorRGB
+    case ChannelU, ChannelV:
+      if model != ColorRGB {
+        model = ColorYUV
+      }
+    }
+  }
+
+  headerLength := tonesLength(calibrationHeader)
+  if len(enc.def.Preamble) != 0 {
+    headerLength = 0
+    for _, step := range enc.def.Preamble {
+      headerLength += step.Length
+    }
+  }
+
+  if enc.def.Vis > 0xff {
+    headerLength += extendedVisLength
+  } else {
+    headerLength += visLength
+  }
+
+  lines := enc.def.lines()
+  black, white := enc.def.frequencyRange()
+  info := newModeInfo(
+    enc.def.Name,
+    model,
+    order,
+    black,
+    white,
+    headerLength,
+    lineLength,
+    (enc.def.Height+lines-1)/lines,
+  )
+
+  info.MaxFrequency = enc.def.maxFrequency()
+  for _, steps := range [][]ModeStep{enc.def.Preamble, enc.def.Sequence} {
+    for _, step := range steps {
+      if step.Type == StepTone && step.Frequency < info.MinFrequency {
+        info.MinFrequency = step.Frequency
+      }
+    }
+  }
+  return info
+}
+
+func (enc *definitionEncoder) Encode(img image.Image) *audio.FloatBuffer {
+  buf, _ := encodeBuffer(enc, img, EncodeOptions{})
+  return buf
+}
+
+func (enc *definitionEncoder) EncodeTo(img image.Image, w io.Writer) error {
+  return encodeStream(enc, img, w, EncodeOptions{})
+}
+
+func (enc *definitionEncoder) audioFormat() *audio.Format {
+  return enc.format
+}
+
+func (enc *definitionEncoder) encode(wr *audioWriter, img image.Image) {
+  black, white := enc.def.frequencyRange()
+  wr.setFrequencyRange(black, white)
+
+  if len(enc.def.Preamble) != 0 {
+    preamble := make([]tone, len(enc.def.Preamble))
+    for i, step := range enc.def.Preamble {
+      preamble[i] = tone{step.Frequency, step.Length}
+    }
+    wr.writePreamble(preamble)
+  } else {
+    wr.writeHeader()
+  }
+
+  if enc.def.Vis > 0xff {
+    wr.writeExtendedVis(enc.def.Vis)
+  } else {
+    wr.writeVis(uint8(enc.def.Vis))
+  }
+
+  lines := enc.def.lines()
+  size := img.Bounds().Size()
+  for y := 0; y < size.Y; y += lines {
+    for _, step := range enc.def.Sequence {
+      if step.Type == StepTone {
+        wr.write(step.Frequency, step.Length)
+        continue
+      }
+
+      for x := 0; x < size.X; x++ {
+        wr.writeValue(enc.value(img, x, y, step), step.Length)
+      }
+    }
+
+    wr.endLine(y/lines, (size.Y+lines-1)/lines)
+  }
+}
+
+// computes the value of a single pixel within the channel of a given scan step
+func (enc *definitionEncoder) value(img image.Image, x int, y int, step ModeStep) float64 {
+  switch step.Channel {
+  case ChannelRed, ChannelGreen, ChannelBlue:
+    r, g, b := convertRGB(img.At(x, y+step.Row))
+    switch step.Channel {
+    case ChannelRed:
+      return r
+    case ChannelGreen:
+      return g
+    default:
+      return b
+    }
+  case ChannelY:
+    yv, _, _ := convertYUV(img.At(x, y+step.Row))
+    return float64(yv) / 255
+  }
+
+  // chrominance is shared between all lines within a group and is thus averaged
+  lines := enc.def.lines()
+  sum := 0
+  for i := 0; i < lines; i++ {
+    _, u, v := convertYUV(img.At(x, y+i))
+    if step.Channel == ChannelU {
+      sum += int(u)
+    } else {
+      sum += int(v)
+    }
+  }
+  return float64(sum/lines) / 255
+}
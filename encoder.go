@@ -17,8 +17,37 @@
 package sstv
 
 import (
+  "errors"
   "github.com/go-audio/audio"
   "image"
+  "io"
+)
+
+var (
+  // indicates that an encoder has been requested for an unknown mode
+  ErrIllegalMode = errors.New("illegal encoding mode")
+  // indicates that no audio format has been given
+  ErrNilFormat = errors.New("audio format must not be nil")
+  // indicates that the sample rate of an audio format is too low to represent the tones of a mode
+  ErrSampleRate = errors.New("sample rate is too low to represent the transmitted tones")
+  // indicates that an audio format does not specify any channels
+  ErrChannels = errors.New("audio format must specify at least one channel")
+  // indicates that an encoder has not been provided by this package and thus cannot be configured
+  ErrUnsupportedEncoder = errors.New("encoder does not support encoding options")
+  // indicates that an output level above full scale has been requested
+  ErrLevel = errors.New("output level must not exceed 0 dBFS")
+  // indicates that a negative transition or fade length has been requested
+  ErrDuration = errors.New("transition and fade lengths must not be negative")
+  // indicates that an unknown sample format has been requested
+  ErrSampleFormat = errors.New("unknown sample format")
+  // indicates that an unknown channel layout has been requested
+  ErrChannelLayout = errors.New("unknown channel layout")
+  // indicates that a signal has been assigned to a channel which is not part of the audio format
+  ErrOutputChannel = errors.New("output channel is not part of the audio format")
+  // indicates that multiple transmissions cannot be combined as their sample rates differ
+  ErrSampleRateMismatch = errors.New("transmissions must share a common sample rate")
+  // indicates that no transmissions have been passed to a multi channel encoding process
+  ErrNoTransmissions = errors.New("at least one transmission is required")
 )
 
 // represents an arbitray SSTV encoder
@@ -29,6 +58,74 @@ type Encoder interface {
   // while this width and height is typically not required for successful encoding, it is
   // recommended to stick to them as most decoders will expect the standard sizes
   Resolution() image.Rectangle
+  // retrieves metadata (such as the expected transmission time) about this transmission format
+  Info() ModeInfo
   // encodes a given image into an SSTV audio signal represented by an array of raw PCM samples
   Encode(image image.Image) *audio.FloatBuffer
+  // encodes a given image into an SSTV audio signal and writes it onto the given stream as raw 16
+  // bit little endian PCM samples
+  //
+  // unlike Encode, the signal is passed to the stream while it is being generated and thus
+  // requires a constant amount of memory regardless of the length of the transmission
+  EncodeTo(image image.Image, w io.Writer) error
+}
+
+// provides the mode specific portion of an encoder
+type modeEncoder interface {
+  Encoder
+  // writes the header and image lines of a given image onto the given writer
+  encode(wr *audioWriter, img image.Image)
+  // retrieves the audio format in which the signal is to be generated
+  audioFormat() *audio.Format
+}
+
+// represents an SSTV encoder which identifies itself using a 16 bit extended VIS code
+//
+// the lower byte of the extended code is returned by Vis() and indicates the presence of the
+// extended code to receivers
+type ExtendedEncoder interface {
+  Encoder
+  // retrieves the extended vis which is to be encoded within the handshake
+  ExtendedVis() uint16
+}
+
+// verifies whether the given audio format is capable of representing tones of up to the given
+// frequency (in Hz)
+func validateFormat(format *audio.Format, frequency float64) error {
+  if format == nil {
+    return ErrNilFormat
+  }
+  if float64(format.SampleRate) <= 2*frequency {
+    return ErrSampleRate
+  }
+  if format.NumChannels <= 0 {
+    return ErrChannels
+  }
+  return nil
+}
+
+// encodes an image using the given mode into an in-memory buffer
+func encodeBuffer(enc modeEncoder, img image.Image, opts EncodeOptions) (*audio.FloatBuffer, error) {
+  if err := opts.validate(enc.audioFormat()); err != nil {
+    return nil, err
+  }
+
+  out := opts.newBufferSink(enc.audioFormat())
+  wr := newWriter(enc.audioFormat(), out, opts)
+  enc.encode(wr, img)
+  if err := wr.close(); err != nil {
+    return nil, err
+  }
+  return out.buf, nil
+}
+
+// encodes an image using the given mode onto an arbitrary stream
+func encodeStream(enc modeEncoder, img image.Image, w io.Writer, opts EncodeOptions) error {
+  if err := opts.validate(enc.audioFormat()); err != nil {
+    return err
+  }
+
+  wr := newWriter(enc.audioFormat(), newStreamSink(w, opts.SampleFormat), opts)
+  enc.encode(wr, img)
+  return wr.close()
 }
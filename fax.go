@@ -0,0 +1,121 @@
+/*
+ * Copyright 2018 Johannes Donath <johannesd@torchmind.com>
+ * and other copyright owners as documented in the project's IP log.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+package sstv
+
+import (
+  "github.com/go-audio/audio"
+  "image"
+  "io"
+)
+
+const fax480Vis = 85
+
+const (
+  fax480SyncFrequency = 1200
+  fax480SyncLength    = 5.12
+
+  fax480PhasingLines  = 20
+  fax480PhasingLength = 262.144
+
+  fax480PulseLength = .512
+)
+
+// provides a FAX480 implementation
+//
+// this implementation encodes grayscale images into 480 lines which are preceded by a series of
+// phasing lines in place of the standard calibration header
+type faxEncoder struct {
+  format *audio.Format
+}
+
+// creates a new FAX480 compatible image encoder
+func NewFAX480(format *audio.Format) (Encoder, error) {
+  if err := validateFormat(format, whiteFrequency); err != nil {
+    return nil, err
+  }
+
+  return &faxEncoder{
+    format: format,
+  }, nil
+}
+
+func (enc *faxEncoder) Vis() uint8 {
+  return fax480Vis
+}
+
+func (enc *faxEncoder) Resolution() image.Rectangle {
+  return image.Rect(0, 0, 512, 480)
+}
+
+func (enc *faxEncoder) Info() ModeInfo {
+  res := enc.Resolution()
+
+  return newModeInfo(
+    "FAX480",
+    ColorGray,
+    []Channel{ChannelY},
+    blackFrequency,
+    whiteFrequency,
+    tonesLength(fax480Preamble())+visLength,
+    fax480SyncLength+float64(res.Dx())*fax480PulseLength,
+    res.Dy(),
+  )
+}
+
+func (enc *faxEncoder) Encode(img image.Image) *audio.FloatBuffer {
+  buf, _ := encodeBuffer(enc, img, EncodeOptions{})
+  return buf
+}
+
+func (enc *faxEncoder) EncodeTo(img image.Image, w io.Writer) error {
+  return encodeStream(enc, img, w, EncodeOptions{})
+}
+
+func (enc *faxEncoder) audioFormat() *audio.Format {
+  return enc.format
+}
+
+func (enc *faxEncoder) encode(wr *audioWriter, img image.Image) {
+  wr.writePreamble(fax480Preamble())
+  wr.writeVis(fax480Vis)
+
+  size := img.Bounds().Size()
+  for y := 0; y < size.Y; y++ {
+    wr.write(fax480SyncFrequency, fax480SyncLength)
+
+    for x := 0; x < size.X; x++ {
+      y, _, _ := convertYUV(img.At(x, y))
+      wr.writeValue(float64(y)/255, fax480PulseLength)
+    }
+
+    wr.endLine(y, size.Y)
+  }
+}
+
+// generates the FAX480 phasing preamble which consists of blank (white) lines along with their
+// respective sync pulses
+func fax480Preamble() []tone {
+  preamble := make([]tone, 0, fax480PhasingLines*2)
+  for i := 0; i < fax480PhasingLines; i++ {
+    preamble = append(preamble,
+      tone{whiteFrequency, fax480PhasingLength},
+      tone{fax480SyncFrequency, fax480SyncLength},
+    )
+  }
+
+  return preamble
+}
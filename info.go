@@ -0,0 +1,110 @@
+/*
+ * Copyright 2018 Johannes Donath <johannesd@torchmind.com>
+ * and other copyright owners as documented in the project's IP log.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+package sstv
+
+import (
+  "math"
+  "time"
+)
+
+// identifies the color model in which a mode transmits its image data
+type ColorModel uint8
+
+const (
+  ColorRGB ColorModel = iota
+  ColorYUV
+  ColorGray
+)
+
+func (c ColorModel) String() string {
+  switch c {
+  case ColorRGB:
+    return "RGB"
+  case ColorYUV:
+    return "YUV"
+  case ColorGray:
+    return "gray"
+  default:
+    return "unknown"
+  }
+}
+
+// length (in milliseconds) of a standard VIS code including its start and stop bits
+const visLength = 10 * bitLength
+
+// length (in milliseconds) of an extended VIS code including its start and stop bits
+const extendedVisLength = 18 * bitLength
+
+// length (in milliseconds) of the digital AVT header
+const avtHeaderLength = avtFrameCount * 16 * avtBitLength
+
+// provides metadata about an encoding mode
+type ModeInfo struct {
+  // human readable name of this mode (such as "Robot 36")
+  Name string
+  // color model in which image data is transmitted
+  ColorModel ColorModel
+  // order in which channels are transmitted within a line (modes which alternate between
+  // chrominance components list the channels of two subsequent lines)
+  ScanOrder []Channel
+  // frequencies (in Hz) which represent black and white pixels respectively
+  BlackFrequency float64
+  WhiteFrequency float64
+  // lowest and highest frequencies (in Hz) which occur within the transmission including its
+  // header and VIS code
+  MinFrequency float64
+  MaxFrequency float64
+  // number of transmitted lines (modes which transmit pairs of image lines at once report the
+  // number of pairs)
+  Lines int
+  // time required to transmit a single line (or pair of lines)
+  LineDuration time.Duration
+  // time required to transmit the entire image including its header
+  Duration time.Duration
+}
+
+// creates a new mode info for a mode which transmits the standard header and VIS code based on the
+// length (in milliseconds) of the transmission header as well as the length of its individual
+// lines
+func newModeInfo(name string, model ColorModel, order []Channel, black float64, white float64, headerLength float64, lineLength float64, lines int) ModeInfo {
+  return ModeInfo{
+    Name:           name,
+    ColorModel:     model,
+    ScanOrder:      order,
+    BlackFrequency: black,
+    WhiteFrequency: white,
+    MinFrequency:   math.Min(trueFrequency, black),
+    MaxFrequency:   math.Max(headerFrequency, white),
+    Lines:          lines,
+    LineDuration:   milliseconds(lineLength),
+    Duration:       milliseconds(headerLength + lineLength*float64(lines)),
+  }
+}
+
+// computes the total length (in milliseconds) of a sequence of tones
+func tonesLength(tones []tone) float64 {
+  var length float64
+  for _, t := range tones {
+    length += t.length
+  }
+  return length
+}
+
+// converts a length in (fractional) milliseconds into a duration
+func milliseconds(length float64) time.Duration {
+  return time.Duration(length * float64(time.Millisecond))
+}
@@ -17,9 +17,10 @@
 package sstv
 
 import (
-  "errors"
+  "fmt"
   "github.com/go-audio/audio"
   "image"
+  "io"
 )
 
 type MartinMode uint8
@@ -27,6 +28,8 @@ type MartinMode uint8
 const (
   Martin1 MartinMode = 44
   Martin2 MartinMode = 40
+  Martin3 MartinMode = 36
+  Martin4 MartinMode = 32
 )
 
 const (
@@ -42,18 +45,29 @@ const (
 
 // provides a Martin implementation as designed by Martin Emmerson
 //
-// this implementation encodes RGB images into 240 lines within either 114 or 58 seconds
+// this implementation encodes RGB images into 256 lines within either 114 or 58 seconds (or 128
+// lines within either 57 or 29 seconds when using the half-height Martin3 and Martin4 modes)
 type martinEncoder struct {
   mode   MartinMode
   format *audio.Format
 }
 
 // creates a new Martin compatible image encoder
-func NewMartin(mode MartinMode, format *audio.Format) Encoder {
+func NewMartin(mode MartinMode, format *audio.Format) (Encoder, error) {
+  switch mode {
+  case Martin1, Martin2, Martin3, Martin4:
+  default:
+    return nil, ErrIllegalMode
+  }
+
+  if err := validateFormat(format, whiteFrequency); err != nil {
+    return nil, err
+  }
+
   return &martinEncoder{
     mode:   mode,
     format: format,
-  }
+  }, nil
 }
 
 func (enc *martinEncoder) Vis() uint8 {
@@ -61,21 +75,72 @@ func (enc *martinEncoder) Vis() uint8 {
 }
 
 func (enc *martinEncoder) Resolution() image.Rectangle {
-  return image.Rect(0, 0, 320, 256)
+  switch enc.mode {
+  case Martin3, Martin4:
+    return image.Rect(0, 0, 320, 128)
+  default:
+    return image.Rect(0, 0, 320, 256)
+  }
 }
 
-func (enc *martinEncoder) Encode(img image.Image) *audio.FloatBuffer {
-  var pulseLength float64
+func (enc *martinEncoder) Info() ModeInfo {
+  res := enc.Resolution()
+  lineLength := martinLineLength + 3*(2*martinSeparatorLength+float64(res.Dx())*enc.pulseLength())
+
+  return newModeInfo(
+    fmt.Sprintf("Martin %d", enc.number()),
+    ColorRGB,
+    []Channel{ChannelGreen, ChannelBlue, ChannelRed},
+    blackFrequency,
+    whiteFrequency,
+    tonesLength(calibrationHeader)+visLength,
+    lineLength,
+    res.Dy(),
+  )
+}
+
+// retrieves the number by which this mode is commonly referred to
+func (enc *martinEncoder) number() int {
   switch enc.mode {
-  case Martin1:
-    pulseLength = martin1PulseLength
   case Martin2:
-    pulseLength = martin2PulseLength
+    return 2
+  case Martin3:
+    return 3
+  case Martin4:
+    return 4
+  default:
+    return 1
+  }
+}
+
+// retrieves the length of a single pixel within this mode
+func (enc *martinEncoder) pulseLength() float64 {
+  switch enc.mode {
+  case Martin1, Martin3:
+    return martin1PulseLength
+  case Martin2, Martin4:
+    return martin2PulseLength
   default:
-    panic(errors.New("illegal encoding mode"))
+    return 0
   }
+}
+
+func (enc *martinEncoder) Encode(img image.Image) *audio.FloatBuffer {
+  buf, _ := encodeBuffer(enc, img, EncodeOptions{})
+  return buf
+}
+
+func (enc *martinEncoder) EncodeTo(img image.Image, w io.Writer) error {
+  return encodeStream(enc, img, w, EncodeOptions{})
+}
+
+func (enc *martinEncoder) audioFormat() *audio.Format {
+  return enc.format
+}
+
+func (enc *martinEncoder) encode(wr *audioWriter, img image.Image) {
+  pulseLength := enc.pulseLength()
 
-  wr := newWriter(enc.format)
   wr.writeHeader()
   wr.writeVis(uint8(enc.mode))
 
@@ -103,7 +168,7 @@ func (enc *martinEncoder) Encode(img image.Image) *audio.FloatBuffer {
 
       wr.write(martinSeparatorFrequency, martinSeparatorLength)
     }
-  }
 
-  return wr.buf
+    wr.endLine(y, size.Y)
+  }
 }
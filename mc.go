@@ -0,0 +1,169 @@
+/*
+ * Copyright 2018 Johannes Donath <johannesd@torchmind.com>
+ * and other copyright owners as documented in the project's IP log.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+package sstv
+
+import (
+  "github.com/go-audio/audio"
+  "image"
+  "io"
+)
+
+type MCMode uint16
+
+const (
+  MC110 MCMode = 0x1923
+  MC140 MCMode = 0x1a23
+  MC180 MCMode = 0x1c23
+)
+
+const (
+  mcSyncFrequency = 1900
+  mcSyncLength    = 9
+
+  mcPorchFrequency = narrowBlackFrequency
+  mcPorchLength    = 1
+
+  mc110PulseLength = .4372
+  mc140PulseLength = .5593
+  mc180PulseLength = .7219
+)
+
+// provides a narrow MC implementation as designed by Makoto Mori for MMSSTV
+//
+// this implementation encodes RGB images into 256 lines within a reduced frequency range
+type mcEncoder struct {
+  mode   MCMode
+  format *audio.Format
+}
+
+// creates a new MC compatible image encoder
+func NewMC(mode MCMode, format *audio.Format) (Encoder, error) {
+  switch mode {
+  case MC110, MC140, MC180:
+  default:
+    return nil, ErrIllegalMode
+  }
+
+  if err := validateFormat(format, whiteFrequency); err != nil {
+    return nil, err
+  }
+
+  return &mcEncoder{
+    mode:   mode,
+    format: format,
+  }, nil
+}
+
+func (enc *mcEncoder) Vis() uint8 {
+  return uint8(enc.mode)
+}
+
+func (enc *mcEncoder) ExtendedVis() uint16 {
+  return uint16(enc.mode)
+}
+
+func (enc *mcEncoder) Resolution() image.Rectangle {
+  return image.Rect(0, 0, 320, 256)
+}
+
+func (enc *mcEncoder) Info() ModeInfo {
+  res := enc.Resolution()
+
+  name := "MC110"
+  switch enc.mode {
+  case MC140:
+    name = "MC140"
+  case MC180:
+    name = "MC180"
+  }
+
+  info := newModeInfo(
+    name,
+    ColorRGB,
+    []Channel{ChannelRed, ChannelGreen, ChannelBlue},
+    narrowBlackFrequency,
+    narrowWhiteFrequency,
+    tonesLength(narrowCalibrationHeader)+extendedVisLength,
+    mcSyncLength+mcPorchLength+3*float64(res.Dx())*enc.pulseLength(),
+    res.Dy(),
+  )
+
+  // narrow modes transmit their header and VIS code within the narrow band as well
+  info.MinFrequency = mcSyncFrequency
+  return info
+}
+
+// retrieves the length of a single pixel within this mode
+func (enc *mcEncoder) pulseLength() float64 {
+  switch enc.mode {
+  case MC110:
+    return mc110PulseLength
+  case MC140:
+    return mc140PulseLength
+  case MC180:
+    return mc180PulseLength
+  default:
+    return 0
+  }
+}
+
+func (enc *mcEncoder) Encode(img image.Image) *audio.FloatBuffer {
+  buf, _ := encodeBuffer(enc, img, EncodeOptions{})
+  return buf
+}
+
+func (enc *mcEncoder) EncodeTo(img image.Image, w io.Writer) error {
+  return encodeStream(enc, img, w, EncodeOptions{})
+}
+
+func (enc *mcEncoder) audioFormat() *audio.Format {
+  return enc.format
+}
+
+func (enc *mcEncoder) encode(wr *audioWriter, img image.Image) {
+  pulseLength := enc.pulseLength()
+
+  wr.setFrequencyRange(narrowBlackFrequency, narrowWhiteFrequency)
+  wr.setVisOffset(narrowVisOffset)
+  wr.writePreamble(narrowCalibrationHeader)
+  wr.writeExtendedVis(uint16(enc.mode))
+
+  size := img.Bounds().Size()
+  for y := 0; y < size.Y; y++ {
+    wr.write(mcSyncFrequency, mcSyncLength)
+    wr.write(mcPorchFrequency, mcPorchLength)
+
+    for i := 0; i < 3; i++ {
+      for x := 0; x < size.X; x++ {
+        r, g, b := convertRGB(img.At(x, y))
+
+        var val float64
+        switch i {
+        case 0:
+          val = r
+        case 1:
+          val = g
+        case 2:
+          val = b
+        }
+        wr.writeValue(val, pulseLength)
+      }
+    }
+
+    wr.endLine(y, size.Y)
+  }
+}
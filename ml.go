@@ -0,0 +1,154 @@
+/*
+ * Copyright 2018 Johannes Donath <johannesd@torchmind.com>
+ * and other copyright owners as documented in the project's IP log.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+package sstv
+
+import (
+  "github.com/go-audio/audio"
+  "image"
+  "io"
+)
+
+type MLMode uint16
+
+const (
+  ML180 MLMode = 0x8523
+  ML240 MLMode = 0x8623
+  ML280 MLMode = 0x8923
+  ML320 MLMode = 0x8a23
+)
+
+const (
+  mlSyncFrequency = 1200
+  mlSyncLength    = 9
+
+  mlPorchFrequency = 1500
+  mlPorchLength    = 1
+
+  ml180PulseLength = .28
+  ml240PulseLength = .374
+  ml280PulseLength = .437
+  ml320PulseLength = .5
+)
+
+// provides an ML implementation as designed by Makoto Mori for MMSSTV
+//
+// this implementation encodes YUV images into 248 pairs of lines which share their chrominance
+// values (similar to PD)
+type mlEncoder struct {
+  mode   MLMode
+  format *audio.Format
+}
+
+// creates a new ML compatible image encoder
+func NewML(mode MLMode, format *audio.Format) (Encoder, error) {
+  switch mode {
+  case ML180, ML240, ML280, ML320:
+  default:
+    return nil, ErrIllegalMode
+  }
+
+  if err := validateFormat(format, whiteFrequency); err != nil {
+    return nil, err
+  }
+
+  return &mlEncoder{
+    mode:   mode,
+    format: format,
+  }, nil
+}
+
+func (enc *mlEncoder) Vis() uint8 {
+  return uint8(enc.mode)
+}
+
+func (enc *mlEncoder) ExtendedVis() uint16 {
+  return uint16(enc.mode)
+}
+
+func (enc *mlEncoder) Resolution() image.Rectangle {
+  return image.Rect(0, 0, 640, 496)
+}
+
+func (enc *mlEncoder) Info() ModeInfo {
+  res := enc.Resolution()
+
+  name := "ML180"
+  switch enc.mode {
+  case ML240:
+    name = "ML240"
+  case ML280:
+    name = "ML280"
+  case ML320:
+    name = "ML320"
+  }
+
+  return newModeInfo(
+    name,
+    ColorYUV,
+    []Channel{ChannelY, ChannelV, ChannelU, ChannelY},
+    blackFrequency,
+    whiteFrequency,
+    tonesLength(calibrationHeader)+extendedVisLength,
+    mlSyncLength+mlPorchLength+4*float64(res.Dx())*enc.pulseLength(),
+    res.Dy()/2,
+  )
+}
+
+// retrieves the length of a single pixel within this mode
+func (enc *mlEncoder) pulseLength() float64 {
+  switch enc.mode {
+  case ML180:
+    return ml180PulseLength
+  case ML240:
+    return ml240PulseLength
+  case ML280:
+    return ml280PulseLength
+  case ML320:
+    return ml320PulseLength
+  default:
+    return 0
+  }
+}
+
+func (enc *mlEncoder) Encode(img image.Image) *audio.FloatBuffer {
+  buf, _ := encodeBuffer(enc, img, EncodeOptions{})
+  return buf
+}
+
+func (enc *mlEncoder) EncodeTo(img image.Image, w io.Writer) error {
+  return encodeStream(enc, img, w, EncodeOptions{})
+}
+
+func (enc *mlEncoder) audioFormat() *audio.Format {
+  return enc.format
+}
+
+func (enc *mlEncoder) encode(wr *audioWriter, img image.Image) {
+  pulseLength := enc.pulseLength()
+
+  wr.writeHeader()
+  wr.writeExtendedVis(uint16(enc.mode))
+
+  size := img.Bounds().Size()
+  for y := 0; y < size.Y; y += 2 {
+    wr.write(mlSyncFrequency, mlSyncLength)
+    wr.write(mlPorchFrequency, mlPorchLength)
+    wr.writeYUVPair(img, y, pulseLength)
+
+    wr.endLine(y/2, (size.Y+1)/2)
+  }
+}
@@ -0,0 +1,154 @@
+/*
+ * Copyright 2018 Johannes Donath <johannesd@torchmind.com>
+ * and other copyright owners as documented in the project's IP log.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+package sstv
+
+import (
+  "github.com/go-audio/audio"
+  "image"
+  "io"
+)
+
+type MNMode uint16
+
+const (
+  MN73  MNMode = 0x0523
+  MN110 MNMode = 0x0923
+  MN140 MNMode = 0x0a23
+)
+
+const (
+  mnSyncFrequency = 1900
+  mnSyncLength    = 9
+
+  mnPorchFrequency = narrowBlackFrequency
+  mnPorchLength    = 1
+
+  mn73PulseLength  = .4375
+  mn110PulseLength = .6633
+  mn140PulseLength = .847
+)
+
+// provides a narrow MN implementation as designed by Makoto Mori for MMSSTV
+//
+// this implementation encodes YUV images into 128 pairs of lines which share their chrominance
+// values (similar to MP) within a reduced frequency range
+type mnEncoder struct {
+  mode   MNMode
+  format *audio.Format
+}
+
+// creates a new MN compatible image encoder
+func NewMN(mode MNMode, format *audio.Format) (Encoder, error) {
+  switch mode {
+  case MN73, MN110, MN140:
+  default:
+    return nil, ErrIllegalMode
+  }
+
+  if err := validateFormat(format, whiteFrequency); err != nil {
+    return nil, err
+  }
+
+  return &mnEncoder{
+    mode:   mode,
+    format: format,
+  }, nil
+}
+
+func (enc *mnEncoder) Vis() uint8 {
+  return uint8(enc.mode)
+}
+
+func (enc *mnEncoder) ExtendedVis() uint16 {
+  return uint16(enc.mode)
+}
+
+func (enc *mnEncoder) Resolution() image.Rectangle {
+  return image.Rect(0, 0, 320, 256)
+}
+
+func (enc *mnEncoder) Info() ModeInfo {
+  res := enc.Resolution()
+
+  name := "MN73"
+  switch enc.mode {
+  case MN110:
+    name = "MN110"
+  case MN140:
+    name = "MN140"
+  }
+
+  info := newModeInfo(
+    name,
+    ColorYUV,
+    []Channel{ChannelY, ChannelV, ChannelU, ChannelY},
+    narrowBlackFrequency,
+    narrowWhiteFrequency,
+    tonesLength(narrowCalibrationHeader)+extendedVisLength,
+    mnSyncLength+mnPorchLength+4*float64(res.Dx())*enc.pulseLength(),
+    res.Dy()/2,
+  )
+
+  // narrow modes transmit their header and VIS code within the narrow band as well
+  info.MinFrequency = mnSyncFrequency
+  return info
+}
+
+// retrieves the length of a single pixel within this mode
+func (enc *mnEncoder) pulseLength() float64 {
+  switch enc.mode {
+  case MN73:
+    return mn73PulseLength
+  case MN110:
+    return mn110PulseLength
+  case MN140:
+    return mn140PulseLength
+  default:
+    return 0
+  }
+}
+
+func (enc *mnEncoder) Encode(img image.Image) *audio.FloatBuffer {
+  buf, _ := encodeBuffer(enc, img, EncodeOptions{})
+  return buf
+}
+
+func (enc *mnEncoder) EncodeTo(img image.Image, w io.Writer) error {
+  return encodeStream(enc, img, w, EncodeOptions{})
+}
+
+func (enc *mnEncoder) audioFormat() *audio.Format {
+  return enc.format
+}
+
+func (enc *mnEncoder) encode(wr *audioWriter, img image.Image) {
+  pulseLength := enc.pulseLength()
+
+  wr.setFrequencyRange(narrowBlackFrequency, narrowWhiteFrequency)
+  wr.setVisOffset(narrowVisOffset)
+  wr.writePreamble(narrowCalibrationHeader)
+  wr.writeExtendedVis(uint16(enc.mode))
+
+  size := img.Bounds().Size()
+  for y := 0; y < size.Y; y += 2 {
+    wr.write(mnSyncFrequency, mnSyncLength)
+    wr.write(mnPorchFrequency, mnPorchLength)
+    wr.writeYUVPair(img, y, pulseLength)
+
+    wr.endLine(y/2, (size.Y+1)/2)
+  }
+}
@@ -0,0 +1,154 @@
+/*
+ * Copyright 2018 Johannes Donath <johannesd@torchmind.com>
+ * and other copyright owners as documented in the project's IP log.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+package sstv
+
+import (
+  "github.com/go-audio/audio"
+  "image"
+  "io"
+)
+
+type MPMode uint16
+
+const (
+  MP73  MPMode = 0x2523
+  MP115 MPMode = 0x2923
+  MP140 MPMode = 0x2a23
+  MP175 MPMode = 0x2c23
+)
+
+const (
+  mpSyncFrequency = 1200
+  mpSyncLength    = 9
+
+  mpPorchFrequency = 1500
+  mpPorchLength    = 1
+
+  mp73PulseLength  = .4375
+  mp115PulseLength = .696875
+  mp140PulseLength = .84375
+  mp175PulseLength = 1.0625
+)
+
+// provides an MP implementation as designed by Makoto Mori for MMSSTV
+//
+// this implementation encodes YUV images into 128 pairs of lines which share their chrominance
+// values (similar to PD)
+type mpEncoder struct {
+  mode   MPMode
+  format *audio.Format
+}
+
+// creates a new MP compatible image encoder
+func NewMP(mode MPMode, format *audio.Format) (Encoder, error) {
+  switch mode {
+  case MP73, MP115, MP140, MP175:
+  default:
+    return nil, ErrIllegalMode
+  }
+
+  if err := validateFormat(format, whiteFrequency); err != nil {
+    return nil, err
+  }
+
+  return &mpEncoder{
+    mode:   mode,
+    format: format,
+  }, nil
+}
+
+func (enc *mpEncoder) Vis() uint8 {
+  return uint8(enc.mode)
+}
+
+func (enc *mpEncoder) ExtendedVis() uint16 {
+  return uint16(enc.mode)
+}
+
+func (enc *mpEncoder) Resolution() image.Rectangle {
+  return image.Rect(0, 0, 320, 256)
+}
+
+func (enc *mpEncoder) Info() ModeInfo {
+  res := enc.Resolution()
+
+  name := "MP73"
+  switch enc.mode {
+  case MP115:
+    name = "MP115"
+  case MP140:
+    name = "MP140"
+  case MP175:
+    name = "MP175"
+  }
+
+  return newModeInfo(
+    name,
+    ColorYUV,
+    []Channel{ChannelY, ChannelV, ChannelU, ChannelY},
+    blackFrequency,
+    whiteFrequency,
+    tonesLength(calibrationHeader)+extendedVisLength,
+    mpSyncLength+mpPorchLength+4*float64(res.Dx())*enc.pulseLength(),
+    res.Dy()/2,
+  )
+}
+
+// retrieves the length of a single pixel within this mode
+func (enc *mpEncoder) pulseLength() float64 {
+  switch enc.mode {
+  case MP73:
+    return mp73PulseLength
+  case MP115:
+    return mp115PulseLength
+  case MP140:
+    return mp140PulseLength
+  case MP175:
+    return mp175PulseLength
+  default:
+    return 0
+  }
+}
+
+func (enc *mpEncoder) Encode(img image.Image) *audio.FloatBuffer {
+  buf, _ := encodeBuffer(enc, img, EncodeOptions{})
+  return buf
+}
+
+func (enc *mpEncoder) EncodeTo(img image.Image, w io.Writer) error {
+  return encodeStream(enc, img, w, EncodeOptions{})
+}
+
+func (enc *mpEncoder) audioFormat() *audio.Format {
+  return enc.format
+}
+
+func (enc *mpEncoder) encode(wr *audioWriter, img image.Image) {
+  pulseLength := enc.pulseLength()
+
+  wr.writeHeader()
+  wr.writeExtendedVis(uint16(enc.mode))
+
+  size := img.Bounds().Size()
+  for y := 0; y < size.Y; y += 2 {
+    wr.write(mpSyncFrequency, mpSyncLength)
+    wr.write(mpPorchFrequency, mpPorchLength)
+    wr.writeYUVPair(img, y, pulseLength)
+
+    wr.endLine(y/2, (size.Y+1)/2)
+  }
+}
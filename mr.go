@@ -0,0 +1,180 @@
+/*
+ * Copyright 2018 Johannes Donath <johannesd@torchmind.com>
+ * and other copyright owners as documented in the project's IP log.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+package sstv
+
+import (
+  "github.com/go-audio/audio"
+  "image"
+  "io"
+)
+
+type MRMode uint16
+
+const (
+  MR73  MRMode = 0x4523
+  MR90  MRMode = 0x4623
+  MR115 MRMode = 0x4923
+  MR140 MRMode = 0x4a23
+  MR175 MRMode = 0x4c23
+)
+
+const (
+  mrSyncFrequency = 1200
+  mrSyncLength    = 9
+
+  mrPorchFrequency = 1500
+  mrPorchLength    = 1
+
+  mrSeparatorFrequency = 1500
+  mrSeparatorLength    = .1
+
+  mr73YLength  = .43125
+  mr73Length   = .215625
+  mr90YLength  = .534375
+  mr90Length   = .2671875
+  mr115YLength = .6875
+  mr115Length  = .34375
+  mr140YLength = .8375
+  mr140Length  = .41875
+  mr175YLength = 1.053125
+  mr175Length  = .5265625
+)
+
+// separates the individual components of an MR line
+var mrSeparator = []tone{
+  {mrSeparatorFrequency, mrSeparatorLength},
+}
+
+// provides an MR implementation as designed by Makoto Mori for MMSSTV
+//
+// this implementation encodes YUV images into 256 lines each of which carries its luminance as
+// well as both of its chrominance components at half the pulse length (similar to Robot72)
+type mrEncoder struct {
+  mode   MRMode
+  format *audio.Format
+}
+
+// creates a new MR compatible image encoder
+func NewMR(mode MRMode, format *audio.Format) (Encoder, error) {
+  switch mode {
+  case MR73, MR90, MR115, MR140, MR175:
+  default:
+    return nil, ErrIllegalMode
+  }
+
+  if err := validateFormat(format, whiteFrequency); err != nil {
+    return nil, err
+  }
+
+  return &mrEncoder{
+    mode:   mode,
+    format: format,
+  }, nil
+}
+
+func (enc *mrEncoder) Vis() uint8 {
+  return uint8(enc.mode)
+}
+
+func (enc *mrEncoder) ExtendedVis() uint16 {
+  return uint16(enc.mode)
+}
+
+func (enc *mrEncoder) Resolution() image.Rectangle {
+  return image.Rect(0, 0, 320, 256)
+}
+
+func (enc *mrEncoder) Info() ModeInfo {
+  res := enc.Resolution()
+  yLength, length := enc.timing()
+
+  name := "MR73"
+  switch enc.mode {
+  case MR90:
+    name = "MR90"
+  case MR115:
+    name = "MR115"
+  case MR140:
+    name = "MR140"
+  case MR175:
+    name = "MR175"
+  }
+
+  return newModeInfo(
+    name,
+    ColorYUV,
+    []Channel{ChannelY, ChannelV, ChannelU},
+    blackFrequency,
+    whiteFrequency,
+    tonesLength(calibrationHeader)+extendedVisLength,
+    mrSyncLength+mrPorchLength+3*tonesLength(mrSeparator)+float64(res.Dx())*(yLength+2*length),
+    res.Dy(),
+  )
+}
+
+// retrieves the luminance and chrominance pixel lengths of this mode
+func (enc *mrEncoder) timing() (float64, float64) {
+  switch enc.mode {
+  case MR73:
+    return mr73YLength, mr73Length
+  case MR90:
+    return mr90YLength, mr90Length
+  case MR115:
+    return mr115YLength, mr115Length
+  case MR140:
+    return mr140YLength, mr140Length
+  case MR175:
+    return mr175YLength, mr175Length
+  default:
+    return 0, 0
+  }
+}
+
+func (enc *mrEncoder) Encode(img image.Image) *audio.FloatBuffer {
+  buf, _ := encodeBuffer(enc, img, EncodeOptions{})
+  return buf
+}
+
+func (enc *mrEncoder) EncodeTo(img image.Image, w io.Writer) error {
+  return encodeStream(enc, img, w, EncodeOptions{})
+}
+
+func (enc *mrEncoder) audioFormat() *audio.Format {
+  return enc.format
+}
+
+func (enc *mrEncoder) encode(wr *audioWriter, img image.Image) {
+  yLength, length := enc.timing()
+
+  wr.writeHeader()
+  wr.writeExtendedVis(uint16(enc.mode))
+
+  size := img.Bounds().Size()
+  for y := 0; y < size.Y; y++ {
+    wr.write(mrSyncFrequency, mrSyncLength)
+    wr.write(mrPorchFrequency, mrPorchLength)
+
+    yl, ul, vl := convertYUVLine(img, y)
+    wr.writeYUVLine(
+      yuvScan{yl, yLength, mrSeparator},
+      yuvScan{vl, length, mrSeparator},
+      yuvScan{ul, length, mrSeparator},
+    )
+
+    wr.endLine(y, size.Y)
+  }
+}
@@ -0,0 +1,160 @@
+/*
+ * Copyright 2018 Johannes Donath <johannesd@torchmind.com>
+ * and other copyright owners as documented in the project's IP log.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+package sstv
+
+import (
+  "context"
+  "github.com/go-audio/audio"
+  "image"
+  "io"
+  "math"
+  "time"
+)
+
+// receives progress updates while an image is being encoded
+//
+// line identifies the (zero based) transmitted line which has just been completed while lines
+// indicates the total number of lines within the transmission (line pairs are counted as a single
+// line in modes which transmit two image rows at once). elapsed contains the amount of audio which
+// has been generated so far
+type ProgressFunc func(line int, lines int, elapsed time.Duration)
+
+// configures an individual encoding process
+type EncodeOptions struct {
+  // permits the cancellation of the encoding process
+  //
+  // cancellation is checked at the end of every line at which point the process is aborted and
+  // the context error is returned (defaults to a context which is never cancelled)
+  Context context.Context
+  // receives progress updates at the end of every line (optional)
+  Progress ProgressFunc
+  // specifies the length of the raised cosine ramps which replace the otherwise instant
+  // transitions between tones (disabled when zero)
+  //
+  // smoother transitions reduce the bandwidth of the signal at the cost of pixel sharpness and
+  // should thus be kept short in comparison with the pulse length of the mode (typically up to
+  // half a millisecond)
+  Transition time.Duration
+  // specifies the peak level of the signal in dBFS (such as -6 in order to leave headroom for
+  // mixers and transmitter ALC)
+  //
+  // the level must not exceed zero (defaults to full scale)
+  Level float64
+  // specifies the length of the raised cosine fade which is applied to the start of the signal
+  // (disabled when zero)
+  FadeIn time.Duration
+  // specifies the length of the raised cosine fade which is applied to the end of the signal
+  // (disabled when zero)
+  //
+  // when enabled, the final samples of a transmission are held back until the encoding process
+  // has completed
+  FadeOut time.Duration
+  // indicates whether buffers are to contain samples within the range of -1 to 1 rather than
+  // samples which are scaled to the value range of the sample format
+  //
+  // this option has no effect on streams which always receive samples in the sample format
+  Normalize bool
+  // specifies the representation of the generated samples (defaults to 16 bit signed integers)
+  //
+  // buffers contain samples which are scaled to the value range of the selected format (unless
+  // normalized) while streams receive the encoded samples
+  SampleFormat SampleFormat
+  // specifies how the signal is distributed among the channels of the audio format (defaults to
+  // duplicating the signal onto every channel)
+  ChannelLayout ChannelLayout
+  // selects the (zero based) channel which carries the signal when using ChannelSingle
+  OutputChannel int
+  // specifies the sample rate at which the signal is generated before it is resampled to the
+  // sample rate of the audio format (disabled when zero)
+  //
+  // generating the signal at a high reference rate (such as 96 kHz) improves the accuracy of
+  // tones and timing at low output rates (such as 8 kHz) at the cost of additional processing
+  ReferenceRate int
+}
+
+// verifies whether the given options are within their permitted ranges for the given audio format
+func (opts *EncodeOptions) validate(format *audio.Format) error {
+  if opts.Level > 0 || math.IsNaN(opts.Level) {
+    return ErrLevel
+  }
+  if opts.Transition < 0 || opts.FadeIn < 0 || opts.FadeOut < 0 {
+    return ErrDuration
+  }
+  if !opts.SampleFormat.valid() {
+    return ErrSampleFormat
+  }
+  if opts.ReferenceRate < 0 || opts.ReferenceRate != 0 && float64(opts.ReferenceRate) <= 2*whiteFrequency {
+    return ErrSampleRate
+  }
+  switch opts.ChannelLayout {
+  case ChannelDuplicate:
+  case ChannelSingle:
+    if opts.OutputChannel < 0 || opts.OutputChannel >= format.NumChannels {
+      return ErrOutputChannel
+    }
+  default:
+    return ErrChannelLayout
+  }
+  return nil
+}
+
+// creates a sink which collects samples within an in-memory buffer of the given format
+func (opts *EncodeOptions) newBufferSink(format *audio.Format) *bufferSink {
+  scale, offset := opts.SampleFormat.scale()
+  if opts.Normalize {
+    scale, offset = 1, 0
+  }
+  return newBufferSink(format, scale, offset)
+}
+
+// retrieves the factor by which full scale samples are multiplied in order to reach the desired
+// output level
+func (opts *EncodeOptions) gain() float64 {
+  return math.Pow(10, opts.Level/20)
+}
+
+// converts a duration into the nearest number of samples at the given sample rate
+func durationSamples(d time.Duration, sampleRate int) int {
+  return int(math.Round(d.Seconds() * float64(sampleRate)))
+}
+
+// encodes a given image into an SSTV audio signal represented by an array of raw PCM samples using
+// the given options
+//
+// returns ErrUnsupportedEncoder when the encoder has not been provided by this package or the
+// context error when the encoding process has been cancelled
+func EncodeWithOptions(enc Encoder, img image.Image, opts EncodeOptions) (*audio.FloatBuffer, error) {
+  mode, ok := enc.(modeEncoder)
+  if !ok {
+    return nil, ErrUnsupportedEncoder
+  }
+  return encodeBuffer(mode, img, opts)
+}
+
+// encodes a given image into an SSTV audio signal and writes it onto the given stream as raw
+// little endian PCM samples in the selected sample format using the given options
+//
+// returns ErrUnsupportedEncoder when the encoder has not been provided by this package or the
+// context error when the encoding process has been cancelled (in which case the stream will only
+// contain a portion of the transmission)
+func EncodeToWithOptions(enc Encoder, img image.Image, w io.Writer, opts EncodeOptions) error {
+  mode, ok := enc.(modeEncoder)
+  if !ok {
+    return ErrUnsupportedEncoder
+  }
+  return encodeStream(mode, img, w, opts)
+}
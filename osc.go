@@ -40,9 +40,8 @@ func (osc *oscillator) sample(frequency float64) float64 {
   return math.Sin(osc.phase) * osc.amplitude
 }
 
-// generates a signal of the indicated length (in milliseconds)
-func (osc *oscillator) signal(frequency float64, length float64) []float64 {
-  samples := int(math.Round(length / 1000 * float64(osc.sampleRate)))
+// generates a signal of the indicated length (in samples)
+func (osc *oscillator) signal(frequency float64, samples int) []float64 {
   values := make([]float64, samples)
 
   for i := 0; i < samples; i++ {
@@ -51,3 +50,14 @@ func (osc *oscillator) signal(frequency float64, length float64) []float64 {
 
   return values
 }
+
+// generates a signal which follows the indicated instantaneous frequency on every sample
+func (osc *oscillator) modulate(frequencies []float64) []float64 {
+  values := make([]float64, len(frequencies))
+
+  for i, frequency := range frequencies {
+    values[i] = osc.sample(frequency)
+  }
+
+  return values
+}
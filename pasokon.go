@@ -19,6 +19,7 @@ package sstv
 import (
   "github.com/go-audio/audio"
   "image"
+  "io"
 )
 
 type PasokonMode uint8
@@ -54,11 +55,21 @@ type pasokonEncoder struct {
 }
 
 // creates a new Pasokon compatible image encoder
-func NewPasokon(mode PasokonMode, format *audio.Format) Encoder {
+func NewPasokon(mode PasokonMode, format *audio.Format) (Encoder, error) {
+  switch mode {
+  case Pasokon3, Pasokon5, Pasokon7:
+  default:
+    return nil, ErrIllegalMode
+  }
+
+  if err := validateFormat(format, whiteFrequency); err != nil {
+    return nil, err
+  }
+
   return &pasokonEncoder{
     mode:   mode,
     format: format,
-  }
+  }, nil
 }
 
 func (enc *pasokonEncoder) Vis() uint8 {
@@ -69,26 +80,64 @@ func (enc *pasokonEncoder) Resolution() image.Rectangle {
   return image.Rect(0, 0, 640, 496)
 }
 
-func (enc *pasokonEncoder) Encode(img image.Image) *audio.FloatBuffer {
-  wr := newWriter(enc.format)
-  wr.writeHeader()
-  wr.writeVis(uint8(enc.mode))
+func (enc *pasokonEncoder) Info() ModeInfo {
+  res := enc.Resolution()
+  lineLength, syncLength, pulseLength := enc.timing()
+
+  var name string
+  switch enc.mode {
+  case Pasokon5:
+    name = "Pasokon P5"
+  case Pasokon7:
+    name = "Pasokon P7"
+  default:
+    name = "Pasokon P3"
+  }
+
+  return newModeInfo(
+    name,
+    ColorRGB,
+    []Channel{ChannelGreen, ChannelBlue, ChannelRed},
+    blackFrequency,
+    whiteFrequency,
+    tonesLength(calibrationHeader)+visLength,
+    lineLength+4*syncLength+3*float64(res.Dx())*pulseLength,
+    res.Dy(),
+  )
+}
 
-  var lineLength, syncLength, pulseLength float64
+// retrieves the line, sync and pixel lengths of this mode
+func (enc *pasokonEncoder) timing() (float64, float64, float64) {
   switch enc.mode {
   case Pasokon3:
-    lineLength = pasokon3LineLength
-    syncLength = pasokon3SyncLength
-    pulseLength = pasokon3PulseLength
+    return pasokon3LineLength, pasokon3SyncLength, pasokon3PulseLength
   case Pasokon5:
-    lineLength = pasokon5LineLength
-    syncLength = pasokon5SyncLength
-    pulseLength = pasokon5PulseLength
+    return pasokon5LineLength, pasokon5SyncLength, pasokon5PulseLength
   case Pasokon7:
-    lineLength = pasokon7LineLength
-    syncLength = pasokon7SyncLength
-    pulseLength = pasokon7PulseLength
+    return pasokon7LineLength, pasokon7SyncLength, pasokon7PulseLength
+  default:
+    return 0, 0, 0
   }
+}
+
+func (enc *pasokonEncoder) Encode(img image.Image) *audio.FloatBuffer {
+  buf, _ := encodeBuffer(enc, img, EncodeOptions{})
+  return buf
+}
+
+func (enc *pasokonEncoder) EncodeTo(img image.Image, w io.Writer) error {
+  return encodeStream(enc, img, w, EncodeOptions{})
+}
+
+func (enc *pasokonEncoder) audioFormat() *audio.Format {
+  return enc.format
+}
+
+func (enc *pasokonEncoder) encode(wr *audioWriter, img image.Image) {
+  wr.writeHeader()
+  wr.writeVis(uint8(enc.mode))
+
+  lineLength, syncLength, pulseLength := enc.timing()
 
   size := img.Bounds().Size()
   for y := 0; y < size.Y; y++ {
@@ -114,7 +163,7 @@ func (enc *pasokonEncoder) Encode(img image.Image) *audio.FloatBuffer {
     }
 
     wr.write(pasokonSyncFrequency, syncLength)
-  }
 
-  return wr.buf
+    wr.endLine(y, size.Y)
+  }
 }
@@ -0,0 +1,182 @@
+/*
+ * Copyright 2018 Johannes Donath <johannesd@torchmind.com>
+ * and other copyright owners as documented in the project's IP log.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+package sstv
+
+import (
+  "fmt"
+  "github.com/go-audio/audio"
+  "image"
+  "io"
+)
+
+type PDMode uint8
+
+const (
+  PD50  PDMode = 93
+  PD90  PDMode = 99
+  PD120 PDMode = 95
+  PD160 PDMode = 98
+  PD180 PDMode = 96
+  PD240 PDMode = 97
+  PD290 PDMode = 94
+)
+
+const (
+  pdSyncFrequency = 1200
+  pdSyncLength    = 20
+
+  pdPorchFrequency = 1500
+  pdPorchLength    = 2.08
+
+  pd50PulseLength  = .286
+  pd90PulseLength  = .532
+  pd120PulseLength = .19
+  pd160PulseLength = .382
+  pd180PulseLength = .286
+  pd240PulseLength = .382
+  pd290PulseLength = .286
+)
+
+// provides a PD implementation as designed by Paul Turner
+//
+// this implementation encodes YUV images in pairs of lines which share their chrominance values
+// thus transmitting the luminance of both lines as well as their averaged R-Y and B-Y values
+type pdEncoder struct {
+  mode   PDMode
+  format *audio.Format
+}
+
+// creates a new PD compatible image encoder
+func NewPD(mode PDMode, format *audio.Format) (Encoder, error) {
+  switch mode {
+  case PD50, PD90, PD120, PD160, PD180, PD240, PD290:
+  default:
+    return nil, ErrIllegalMode
+  }
+
+  if err := validateFormat(format, whiteFrequency); err != nil {
+    return nil, err
+  }
+
+  return &pdEncoder{
+    mode:   mode,
+    format: format,
+  }, nil
+}
+
+func (enc *pdEncoder) Vis() uint8 {
+  return uint8(enc.mode)
+}
+
+func (enc *pdEncoder) Resolution() image.Rectangle {
+  switch enc.mode {
+  case PD50, PD90:
+    return image.Rect(0, 0, 320, 256)
+  case PD160:
+    return image.Rect(0, 0, 512, 400)
+  case PD290:
+    return image.Rect(0, 0, 800, 616)
+  default:
+    return image.Rect(0, 0, 640, 496)
+  }
+}
+
+func (enc *pdEncoder) Info() ModeInfo {
+  res := enc.Resolution()
+
+  return newModeInfo(
+    fmt.Sprintf("PD%d", enc.number()),
+    ColorYUV,
+    []Channel{ChannelY, ChannelV, ChannelU, ChannelY},
+    blackFrequency,
+    whiteFrequency,
+    tonesLength(calibrationHeader)+visLength,
+    pdSyncLength+pdPorchLength+4*float64(res.Dx())*enc.pulseLength(),
+    res.Dy()/2,
+  )
+}
+
+// retrieves the number by which this mode is commonly referred to
+func (enc *pdEncoder) number() int {
+  switch enc.mode {
+  case PD50:
+    return 50
+  case PD90:
+    return 90
+  case PD120:
+    return 120
+  case PD160:
+    return 160
+  case PD180:
+    return 180
+  case PD240:
+    return 240
+  default:
+    return 290
+  }
+}
+
+// retrieves the length of a single pixel within this mode
+func (enc *pdEncoder) pulseLength() float64 {
+  switch enc.mode {
+  case PD50:
+    return pd50PulseLength
+  case PD90:
+    return pd90PulseLength
+  case PD120:
+    return pd120PulseLength
+  case PD160:
+    return pd160PulseLength
+  case PD180:
+    return pd180PulseLength
+  case PD240:
+    return pd240PulseLength
+  case PD290:
+    return pd290PulseLength
+  default:
+    return 0
+  }
+}
+
+func (enc *pdEncoder) Encode(img image.Image) *audio.FloatBuffer {
+  buf, _ := encodeBuffer(enc, img, EncodeOptions{})
+  return buf
+}
+
+func (enc *pdEncoder) EncodeTo(img image.Image, w io.Writer) error {
+  return encodeStream(enc, img, w, EncodeOptions{})
+}
+
+func (enc *pdEncoder) audioFormat() *audio.Format {
+  return enc.format
+}
+
+func (enc *pdEncoder) encode(wr *audioWriter, img image.Image) {
+  wr.writeHeader()
+  wr.writeVis(uint8(enc.mode))
+
+  pulseLength := enc.pulseLength()
+
+  size := img.Bounds().Size()
+  for y := 0; y < size.Y; y += 2 {
+    wr.write(pdSyncFrequency, pdSyncLength)
+    wr.write(pdPorchFrequency, pdPorchLength)
+    wr.writeYUVPair(img, y, pulseLength)
+
+    wr.endLine(y/2, (size.Y+1)/2)
+  }
+}
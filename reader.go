@@ -0,0 +1,52 @@
+/*
+ * Copyright 2018 Johannes Donath <johannesd@torchmind.com>
+ * and other copyright owners as documented in the project's IP log.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+package sstv
+
+import (
+  "image"
+  "io"
+)
+
+// creates a reader which lazily encodes a given image into raw 16 bit little endian PCM samples
+// while they are being read
+//
+// samples are only generated as fast as they are consumed; the reader must be closed once it is
+// no longer needed (even when it has not been read in its entirety) in order to stop the encoder
+func NewReader(enc Encoder, img image.Image) io.ReadCloser {
+  r, w := io.Pipe()
+
+  go func() {
+    w.CloseWithError(enc.EncodeTo(img, w))
+  }()
+
+  return r
+}
+
+// creates a reader which lazily encodes a given image into raw little endian PCM samples in the
+// selected sample format using the given options
+//
+// errors which are encountered while encoding (such as invalid options or cancellation) are
+// reported by the reader
+func NewReaderWithOptions(enc Encoder, img image.Image, opts EncodeOptions) io.ReadCloser {
+  r, w := io.Pipe()
+
+  go func() {
+    w.CloseWithError(EncodeToWithOptions(enc, img, w, opts))
+  }()
+
+  return r
+}
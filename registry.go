@@ -0,0 +1,232 @@
+/*
+ * Copyright 2018 Johannes Donath <johannesd@torchmind.com>
+ * and other copyright owners as documented in the project's IP log.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+package sstv
+
+import (
+  "github.com/go-audio/audio"
+  "strings"
+  "sync"
+)
+
+// describes a single mode which is known to the package registry
+type Mode struct {
+  // unique lower case identifier of this mode (such as "robot36")
+  Name string
+  // VIS code (or 16 bit extended VIS code) which identifies this mode
+  Vis uint16
+  // creates a new encoder for this mode
+  New func(format *audio.Format) (Encoder, error)
+}
+
+var registryLock sync.RWMutex
+
+// modes are registered in order of popularity in order to resolve the few VIS codes which are
+// shared between multiple modes in favor of the most common one
+var registry = []Mode{
+  martinMode("martin1", Martin1),
+  martinMode("martin2", Martin2),
+  martinMode("martin3", Martin3),
+  martinMode("martin4", Martin4),
+  scottieMode("scottie1", Scottie1),
+  scottieMode("scottie2", Scottie2),
+  scottieMode("scottie3", Scottie3),
+  scottieMode("scottie4", Scottie4),
+  scottieMode("scottiedx", ScottieDx),
+  robotMode("robot24", Robot24),
+  robotMode("robot36", Robot36),
+  robotMode("robot72", Robot72),
+  robotMode("robot8bw", Robot8BW),
+  robotMode("robot12bw", Robot12BW),
+  robotMode("robot24bw", Robot24BW),
+  robotMode("robot36bw", Robot36BW),
+  pdMode("pd50", PD50),
+  pdMode("pd90", PD90),
+  pdMode("pd120", PD120),
+  pdMode("pd160", PD160),
+  pdMode("pd180", PD180),
+  pdMode("pd240", PD240),
+  pdMode("pd290", PD290),
+  pasokonMode("pasokon3", Pasokon3),
+  pasokonMode("pasokon5", Pasokon5),
+  pasokonMode("pasokon7", Pasokon7),
+  wrasseMode("sc2-30", WrasseSC230),
+  wrasseMode("sc2-60", WrasseSC260),
+  wrasseMode("sc2-120", WrasseSC2120),
+  wrasseMode("sc2-180", WrasseSC2180),
+  mpMode("mp73", MP73),
+  mpMode("mp115", MP115),
+  mpMode("mp140", MP140),
+  mpMode("mp175", MP175),
+  mrMode("mr73", MR73),
+  mrMode("mr90", MR90),
+  mrMode("mr115", MR115),
+  mrMode("mr140", MR140),
+  mrMode("mr175", MR175),
+  mlMode("ml180", ML180),
+  mlMode("ml240", ML240),
+  mlMode("ml280", ML280),
+  mlMode("ml320", ML320),
+  mnMode("mn73", MN73),
+  mnMode("mn110", MN110),
+  mnMode("mn140", MN140),
+  mcMode("mc110", MC110),
+  mcMode("mc140", MC140),
+  mcMode("mc180", MC180),
+  {Name: "fax480", Vis: fax480Vis, New: NewFAX480},
+  avtMode("avt24", AVT24),
+  avtMode("avt90", AVT90),
+  avtMode("avt94", AVT94),
+  avtMode("avt125", AVT125),
+  avtMode("avt188", AVT188),
+}
+
+// retrieves a list of all registered modes
+func Modes() []Mode {
+  registryLock.RLock()
+  defer registryLock.RUnlock()
+
+  modes := make([]Mode, len(registry))
+  copy(modes, registry)
+  return modes
+}
+
+// retrieves a registered mode based on its (case insensitive) name
+func ModeByName(name string) (Mode, bool) {
+  registryLock.RLock()
+  defer registryLock.RUnlock()
+
+  for _, mode := range registry {
+    if strings.EqualFold(mode.Name, name) {
+      return mode, true
+    }
+  }
+  return Mode{}, false
+}
+
+// retrieves a registered mode based on its VIS (or extended VIS) code
+//
+// when multiple modes share the same code, the mode which has been registered first is returned
+func ModeByVIS(vis uint16) (Mode, bool) {
+  registryLock.RLock()
+  defer registryLock.RUnlock()
+
+  for _, mode := range registry {
+    if mode.Vis == vis {
+      return mode, true
+    }
+  }
+  return Mode{}, false
+}
+
+// registers a custom mode with the package registry
+//
+// if a mode of the same name has already been registered, it is replaced
+func RegisterMode(mode Mode) {
+  registryLock.Lock()
+  defer registryLock.Unlock()
+
+  for i, existing := range registry {
+    if strings.EqualFold(existing.Name, mode.Name) {
+      registry[i] = mode
+      return
+    }
+  }
+  registry = append(registry, mode)
+}
+
+// registers a mode definition with the package registry
+func RegisterDefinition(def *ModeDefinition) {
+  RegisterMode(Mode{
+    Name: def.Name,
+    Vis:  def.Vis,
+    New: func(format *audio.Format) (Encoder, error) {
+      return NewDefinitionEncoder(def, format)
+    },
+  })
+}
+
+func martinMode(name string, mode MartinMode) Mode {
+  return Mode{name, uint16(mode), func(format *audio.Format) (Encoder, error) {
+    return NewMartin(mode, format)
+  }}
+}
+
+func scottieMode(name string, mode ScottieMode) Mode {
+  return Mode{name, uint16(mode), func(format *audio.Format) (Encoder, error) {
+    return NewScottie(mode, format)
+  }}
+}
+
+func robotMode(name string, mode RobotMode) Mode {
+  return Mode{name, uint16(mode), func(format *audio.Format) (Encoder, error) {
+    return NewRobot(mode, format)
+  }}
+}
+
+func pdMode(name string, mode PDMode) Mode {
+  return Mode{name, uint16(mode), func(format *audio.Format) (Encoder, error) {
+    return NewPD(mode, format)
+  }}
+}
+
+func pasokonMode(name string, mode PasokonMode) Mode {
+  return Mode{name, uint16(mode), func(format *audio.Format) (Encoder, error) {
+    return NewPasokon(mode, format)
+  }}
+}
+
+func wrasseMode(name string, mode WrasseMode) Mode {
+  return Mode{name, uint16(mode), func(format *audio.Format) (Encoder, error) {
+    return NewWrasse(mode, format)
+  }}
+}
+
+func mpMode(name string, mode MPMode) Mode {
+  return Mode{name, uint16(mode), func(format *audio.Format) (Encoder, error) {
+    return NewMP(mode, format)
+  }}
+}
+
+func mrMode(name string, mode MRMode) Mode {
+  return Mode{name, uint16(mode), func(format *audio.Format) (Encoder, error) {
+    return NewMR(mode, format)
+  }}
+}
+
+func mlMode(name string, mode MLMode) Mode {
+  return Mode{name, uint16(mode), func(format *audio.Format) (Encoder, error) {
+    return NewML(mode, format)
+  }}
+}
+
+func mnMode(name string, mode MNMode) Mode {
+  return Mode{name, uint16(mode), func(format *audio.Format) (Encoder, error) {
+    return NewMN(mode, format)
+  }}
+}
+
+func mcMode(name string, mode MCMode) Mode {
+  return Mode{name, uint16(mode), func(format *audio.Format) (Encoder, error) {
+    return NewMC(mode, format)
+  }}
+}
+
+func avtMode(name string, mode AVTMode) Mode {
+  return Mode{name, uint16(mode), func(format *audio.Format) (Encoder, error) {
+    return NewAVT(mode, format)
+  }}
+}
@@ -0,0 +1,210 @@
+/*
+ * Copyright 2018 Johannes Donath <johannesd@torchmind.com>
+ * and other copyright owners as documented in the project's IP log.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+package sstv
+
+import "math"
+
+const (
+  // number of zero crossings of the interpolation kernel on either side of its center
+  resamplerZeroCrossings = 16
+  // number of kernel values which are precomputed per input sample
+  resamplerResolution = 256
+  // fraction of the lower Nyquist frequency which is passed by the anti-aliasing filter
+  resamplerCutoff = .9
+  // maximum number of distinct output sample positions for which kernels are cached
+  resamplerMaxPhases = 4096
+)
+
+// converts a signal between two sample rates using a windowed sinc interpolation kernel
+//
+// the kernel doubles as an anti-aliasing filter when the sample rate is reduced and is cut off
+// slightly below the lower of both Nyquist frequencies. As the kernel extends into the future of
+// the signal, samples are held back until enough input is available (or the sink is flushed)
+type resampler struct {
+  out        sampleSink
+  inputRate  int64
+  outputRate int64
+  // precomputed kernel values at fractional offsets of 1/resamplerResolution input samples
+  kernel []float64
+  // number of input samples which are covered by the kernel on either side of its center
+  half int
+  // kernels for each distinct position of output samples between two input samples (nil when
+  // there are too many positions to cache)
+  phases [][]float64
+  // input samples starting at the index stored within base
+  buf  []float64
+  base int64
+  // total number of input samples which have been received so far
+  received int64
+  // total number of output samples which have been generated so far
+  generated int64
+}
+
+// creates a new resampler which converts samples from the given input rate to the given output
+// rate before passing them to another sink
+func newResampler(out sampleSink, inputRate int, outputRate int) *resampler {
+  cutoff := resamplerCutoff * math.Min(1, float64(outputRate)/float64(inputRate))
+  half := int(math.Ceil(resamplerZeroCrossings / cutoff))
+
+  kernel := make([]float64, half*resamplerResolution+1)
+  for i := range kernel {
+    d := float64(i) / resamplerResolution
+    kernel[i] = cutoff * sinc(cutoff*d) * blackman(d/float64(half))
+  }
+
+  r := &resampler{
+    out:        out,
+    inputRate:  int64(inputRate),
+    outputRate: int64(outputRate),
+    kernel:     kernel,
+    half:       half,
+  }
+
+  // output samples are located at a fixed set of positions between input samples when both rates
+  // share a large common divisor (as is the case with all standard rates)
+  if phases := outputRate / gcd(inputRate, outputRate); phases <= resamplerMaxPhases {
+    r.phases = make([][]float64, phases)
+  }
+  return r
+}
+
+func (r *resampler) writeSamples(samples []float64) error {
+  r.buf = append(r.buf, samples...)
+  r.received += int64(len(samples))
+  return r.resample(false)
+}
+
+func (r *resampler) flush() error {
+  if err := r.resample(true); err != nil {
+    return err
+  }
+  return r.out.flush()
+}
+
+// generates all output samples for which the kernel is covered by the received input and discards
+// the input which is no longer required
+//
+// once the end of the signal has been reached, it is assumed to be silent beyond its end and all
+// remaining output samples are generated
+func (r *resampler) resample(final bool) error {
+  total := (r.received*r.outputRate + r.inputRate/2) / r.inputRate
+  half := int64(r.half)
+
+  var values []float64
+  for {
+    // position of the next output sample in input samples
+    pos := r.generated * r.inputRate
+    center := pos / r.outputRate
+    frac := float64(pos%r.outputRate) / float64(r.outputRate)
+
+    if final && r.generated >= total || !final && center+half >= r.received {
+      break
+    }
+
+    lo := center - half + 1
+    if lo < r.base {
+      lo = r.base
+    }
+    hi := center + half
+    if hi >= r.received {
+      hi = r.received - 1
+    }
+
+    value := 0.0
+    if r.phases != nil {
+      weights := r.phase(pos % r.outputRate)
+      for k := lo; k <= hi; k++ {
+        value += r.buf[k-r.base] * weights[k-center+half-1]
+      }
+    } else {
+      for k := lo; k <= hi; k++ {
+        value += r.buf[k-r.base] * r.kernelAt(math.Abs(float64(k-center)-frac))
+      }
+    }
+    // the ringing of the kernel may push samples beyond full scale in the vicinity of abrupt
+    // transitions and thus the output is limited in order to prevent them from wrapping
+    values = append(values, saturate(value))
+    r.generated++
+  }
+
+  // retain the input which is still covered by the kernel of the next output sample
+  if next := r.generated*r.inputRate/r.outputRate - half + 1; next > r.base {
+    drop := next - r.base
+    if drop > int64(len(r.buf)) {
+      drop = int64(len(r.buf))
+    }
+    r.buf = append(r.buf[:0], r.buf[drop:]...)
+    r.base += drop
+  }
+
+  if len(values) == 0 {
+    return nil
+  }
+  return r.out.writeSamples(values)
+}
+
+// retrieves the kernel value at the given distance (in input samples) from its center using linear
+// interpolation between the precomputed values
+func (r *resampler) kernelAt(d float64) float64 {
+  x := d * resamplerResolution
+  i := int(x)
+  if i >= len(r.kernel)-1 {
+    return 0
+  }
+  t := x - float64(i)
+  return r.kernel[i]*(1-t) + r.kernel[i+1]*t
+}
+
+// retrieves the kernel weights of the input samples surrounding an output sample at the given
+// offset (in units of 1/outputRate input samples) from the preceding input sample
+func (r *resampler) phase(offset int64) []float64 {
+  i := offset * int64(len(r.phases)) / r.outputRate
+  if r.phases[i] == nil {
+    frac := float64(offset) / float64(r.outputRate)
+    weights := make([]float64, 2*r.half)
+    for j := range weights {
+      weights[j] = r.kernelAt(math.Abs(float64(j-r.half+1) - frac))
+    }
+    r.phases[i] = weights
+  }
+  return r.phases[i]
+}
+
+// computes the greatest common divisor of two numbers
+func gcd(a int, b int) int {
+  for b != 0 {
+    a, b = b, a%b
+  }
+  return a
+}
+
+// computes the normalized sinc function
+func sinc(x float64) float64 {
+  if x == 0 {
+    return 1
+  }
+  return math.Sin(math.Pi*x) / (math.Pi * x)
+}
+
+// computes the Blackman window at the given position relative to its center (where -1 and 1 mark
+// its edges)
+func blackman(x float64) float64 {
+  if x <= -1 || x >= 1 {
+    return 0
+  }
+  return .42 + .5*math.Cos(math.Pi*x) + .08*math.Cos(2*math.Pi*x)
+}
@@ -0,0 +1,145 @@
+/*
+ * Copyright 2018 Johannes Donath <johannesd@torchmind.com>
+ * and other copyright owners as documented in the project's IP log.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+package sstv
+
+import (
+  "github.com/go-audio/audio"
+  "image"
+  "math"
+  "testing"
+)
+
+// retains all samples which are passed to it
+type collectingSink struct {
+  samples []float64
+}
+
+func (s *collectingSink) writeSamples(samples []float64) error {
+  s.samples = append(s.samples, samples...)
+  return nil
+}
+
+func (s *collectingSink) flush() error {
+  return nil
+}
+
+// passes a signal to a resampler in segments of varying size and returns its output
+func resample(signal []float64, inputRate int, outputRate int) []float64 {
+  out := &collectingSink{}
+  r := newResampler(out, inputRate, outputRate)
+
+  sizes := []int{1, 0, 17, 480, 3, 1024}
+  for i, n := 0, 0; i < len(signal); n++ {
+    end := i + sizes[n%len(sizes)]
+    if end > len(signal) {
+      end = len(signal)
+    }
+
+    r.writeSamples(signal[i:end])
+    i = end
+  }
+  r.flush()
+
+  return out.samples
+}
+
+// generates a sine tone at the given frequency and sample rate
+func sineTone(frequency float64, rate int, n int) []float64 {
+  signal := make([]float64, n)
+  for i := range signal {
+    signal[i] = math.Sin(2 * math.Pi * frequency * float64(i) / float64(rate))
+  }
+  return signal
+}
+
+func TestResamplerLength(t *testing.T) {
+  rates := [][2]int{{96000, 8000}, {8000, 96000}, {44100, 48000}, {48000, 44100}, {11025, 8000}, {22050, 22050}}
+
+  for _, rate := range rates {
+    for _, n := range []int{0, 1, 100, 12345} {
+      expected := int(math.Round(float64(n) * float64(rate[1]) / float64(rate[0])))
+
+      if actual := len(resample(make([]float64, n), rate[0], rate[1])); actual != expected {
+        t.Errorf("%d to %d Hz: resampled %d samples into %d (expected %d)", rate[0], rate[1], n, actual, expected)
+      }
+    }
+  }
+}
+
+func TestResamplerTone(t *testing.T) {
+  rates := [][2]int{{96000, 8000}, {8000, 48000}, {44100, 11025}}
+
+  for _, rate := range rates {
+    signal := resample(sineTone(1000, rate[0], rate[0]), rate[0], rate[1])
+    expected := sineTone(1000, rate[1], rate[1])
+
+    // the signal is not band limited at its edges
+    for i := len(signal) / 4; i < len(signal)*3/4; i++ {
+      if math.Abs(signal[i]-expected[i]) > 1e-3 {
+        t.Fatalf("%d to %d Hz: sample %d deviates from its expected value (%f instead of %f)", rate[0], rate[1], i, signal[i], expected[i])
+      }
+    }
+  }
+}
+
+func TestResamplerLevel(t *testing.T) {
+  // abrupt steps at full scale cause the most overshoot
+  signal := make([]float64, 96000)
+  for i := range signal {
+    signal[i] = 1
+    if (i/37)%2 == 1 {
+      signal[i] = -1
+    }
+  }
+
+  for i, sample := range resample(signal, 96000, 8000) {
+    if sample < -1 || sample > 1 {
+      t.Fatalf("sample %d exceeds full scale: %f", i, sample)
+    }
+  }
+}
+
+func TestResampledEncoding(t *testing.T) {
+  format := &audio.Format{NumChannels: 1, SampleRate: 8000}
+  enc, err := NewMartin(Martin2, format)
+  if err != nil {
+    t.Fatal(err)
+  }
+  img := image.NewRGBA(enc.Resolution())
+
+  direct, err := EncodeWithOptions(enc, img, EncodeOptions{Normalize: true})
+  if err != nil {
+    t.Fatal(err)
+  }
+  resampled, err := EncodeWithOptions(enc, img, EncodeOptions{Normalize: true, ReferenceRate: 96000})
+  if err != nil {
+    t.Fatal(err)
+  }
+
+  if resampled.Format.SampleRate != format.SampleRate {
+    t.Errorf("encoded at %d Hz (expected %d Hz)", resampled.Format.SampleRate, format.SampleRate)
+  }
+  if diff := len(resampled.Data) - len(direct.Data); diff < -1 || diff > 1 {
+    t.Errorf("encoded %d samples (expected %d)", len(resampled.Data), len(direct.Data))
+  }
+
+  for i, sample := range resampled.Data {
+    if sample < -1 || sample > 1 {
+      t.Fatalf("sample %d exceeds full scale: %f", i, sample)
+    }
+  }
+}
@@ -17,16 +17,23 @@
 package sstv
 
 import (
-  "errors"
+  "fmt"
   "github.com/go-audio/audio"
   "image"
+  "io"
 )
 
 type RobotMode uint8
 
 const (
+  Robot24 RobotMode = 4
   Robot36 RobotMode = 8
   Robot72 RobotMode = 12
+
+  Robot8BW  RobotMode = 2
+  Robot12BW RobotMode = 6
+  Robot24BW RobotMode = 10
+  Robot36BW RobotMode = 14
 )
 
 const (
@@ -44,28 +51,62 @@ const (
   robotSeparatorLength        = 4.5
 )
 
+// separates the luminance from the first chrominance component in modes which transmit both
+// chrominance components on every line
+var robotEvenSeparator = []tone{
+  {robotEvenSeparatorFrequency, robotSeparatorLength},
+  {robotPorchFrequency, robotPorchLength},
+}
+
+// separates both chrominance components in modes which transmit them on every line
+var robotOddSeparator = []tone{
+  {robotOddSeparatorFrequency, robotSeparatorLength},
+  {robotSyncFrequency, robotPorchLength},
+}
+
 const (
+  robot24YLength = 0.55
+  robot24Length  = 0.275
+
   robot36YLength = 0.275
   robot36Length  = 0.1375
 
   robot72YLength = 0.43125
   robot72Length  = 0.215625
+
+  robotBWLineLength = 7
+
+  robot8BWLength  = 0.3729
+  robot12BWLength = 0.58125
+  robot24BWLength = 0.290625
+  robot36BWLength = 0.446875
 )
 
-// provides a Scottie implementation as designed by Eddie Murphy
+// provides a Robot implementation
 //
-// this implementation encodes RGB images into 240 lines
+// this implementation encodes YUV images into either 120 or 240 lines (or grayscale images into
+// either 120 or 240 lines when using one of the black and white modes)
 type robotEncoder struct {
   mode   RobotMode
   format *audio.Format
 }
 
 // creates a new Martin compatible image encoder
-func NewRobot(mode RobotMode, format *audio.Format) Encoder {
+func NewRobot(mode RobotMode, format *audio.Format) (Encoder, error) {
+  switch mode {
+  case Robot24, Robot36, Robot72, Robot8BW, Robot12BW, Robot24BW, Robot36BW:
+  default:
+    return nil, ErrIllegalMode
+  }
+
+  if err := validateFormat(format, whiteFrequency); err != nil {
+    return nil, err
+  }
+
   return &robotEncoder{
     mode:   mode,
     format: format,
-  }
+  }, nil
 }
 
 func (enc *robotEncoder) Vis() uint8 {
@@ -73,26 +114,137 @@ func (enc *robotEncoder) Vis() uint8 {
 }
 
 func (enc *robotEncoder) Resolution() image.Rectangle {
-  return image.Rect(0, 0, 320, 240)
+  switch enc.mode {
+  case Robot24, Robot8BW, Robot12BW:
+    return image.Rect(0, 0, 160, 120)
+  default:
+    return image.Rect(0, 0, 320, 240)
+  }
+}
+
+func (enc *robotEncoder) Info() ModeInfo {
+  res := enc.Resolution()
+  width := float64(res.Dx())
+  sync := float64(robotLineLength + robotSyncLength)
+  separator := float64(robotSeparatorLength + robotPorchLength)
+
+  var name string
+  var model ColorModel
+  var order []Channel
+  var lineLength float64
+  switch enc.mode {
+  case Robot24:
+    name = "Robot 24"
+    model = ColorYUV
+    order = []Channel{ChannelY, ChannelU, ChannelV}
+    lineLength = sync + width*(robot24YLength+2*robot24Length) + 2*separator
+  case Robot36:
+    name = "Robot 36"
+    model = ColorYUV
+    order = []Channel{ChannelY, ChannelU, ChannelY, ChannelV}
+    lineLength = sync + width*(robot36YLength+robot36Length) + separator
+  case Robot72:
+    name = "Robot 72"
+    model = ColorYUV
+    order = []Channel{ChannelY, ChannelU, ChannelV}
+    lineLength = sync + width*(robot72YLength+2*robot72Length) + 2*separator
+  default:
+    name = fmt.Sprintf("Robot %d B/W", enc.bwNumber())
+    model = ColorGray
+    order = []Channel{ChannelY}
+    lineLength = robotBWLineLength + width*enc.bwPulseLength()
+  }
+
+  return newModeInfo(
+    name,
+    model,
+    order,
+    blackFrequency,
+    whiteFrequency,
+    tonesLength(calibrationHeader)+visLength,
+    lineLength,
+    res.Dy(),
+  )
+}
+
+// retrieves the number by which a black and white mode is commonly referred to
+func (enc *robotEncoder) bwNumber() int {
+  switch enc.mode {
+  case Robot8BW:
+    return 8
+  case Robot12BW:
+    return 12
+  case Robot24BW:
+    return 24
+  default:
+    return 36
+  }
+}
+
+// retrieves the length of a single pixel within a black and white mode
+func (enc *robotEncoder) bwPulseLength() float64 {
+  switch enc.mode {
+  case Robot8BW:
+    return robot8BWLength
+  case Robot12BW:
+    return robot12BWLength
+  case Robot24BW:
+    return robot24BWLength
+  case Robot36BW:
+    return robot36BWLength
+  default:
+    return 0
+  }
 }
 
 func (enc *robotEncoder) Encode(img image.Image) *audio.FloatBuffer {
-  wr := newWriter(enc.format)
+  buf, _ := encodeBuffer(enc, img, EncodeOptions{})
+  return buf
+}
+
+func (enc *robotEncoder) EncodeTo(img image.Image, w io.Writer) error {
+  return encodeStream(enc, img, w, EncodeOptions{})
+}
+
+func (enc *robotEncoder) audioFormat() *audio.Format {
+  return enc.format
+}
+
+func (enc *robotEncoder) encode(wr *audioWriter, img image.Image) {
   wr.writeHeader()
   wr.writeVis(uint8(enc.mode))
 
   // different from other encoders, Robot provides two completely different encoding types which
   // share little to nothing and thus we 'll need two separate encoding methods
   switch enc.mode {
+  case Robot24:
+    enc.encode24(wr, img)
   case Robot36:
     enc.encode36(wr, img)
   case Robot72:
     enc.encode72(wr, img)
-  default:
-    panic(errors.New("illegal encoding mode"))
+  case Robot8BW, Robot12BW, Robot24BW, Robot36BW:
+    enc.encodeBW(wr, img, enc.bwPulseLength())
   }
+}
 
-  return wr.buf
+func (enc *robotEncoder) encode24(wr *audioWriter, img image.Image) {
+  size := img.Bounds().Size()
+  ymap, umap, vmap := enc.generateYUVMap(img)
+  for y := 0; y < size.Y; y++ {
+    wr.write(robotLineFrequency, robotLineLength)
+    wr.write(robotSyncFrequency, robotSyncLength)
+
+    // unlike Robot36, Robot24 transmits both of its (sub-sampled) chrominance components on every
+    // line in order to make up for its reduced vertical resolution
+    wr.writeYUVLine(
+      yuvScan{ymap[y*size.X : (y+1)*size.X], robot24YLength, robotEvenSeparator},
+      yuvScan{umap[y*size.X : (y+1)*size.X], robot24Length, robotOddSeparator},
+      yuvScan{vmap[y*size.X : (y+1)*size.X], robot24Length, nil},
+    )
+
+    wr.endLine(y, size.Y)
+  }
 }
 
 func (enc *robotEncoder) encode36(wr *audioWriter, img image.Image) {
@@ -101,36 +253,25 @@ func (enc *robotEncoder) encode36(wr *audioWriter, img image.Image) {
   for y := 0; y < size.Y; y++ {
     wr.write(robotLineFrequency, robotLineLength)
     wr.write(robotSyncFrequency, robotSyncLength)
-    even := y%2 == 0
-
-    for i := 0; i < 2; i++ {
-      for x := 0; x < size.X; x++ {
-        mapIndex := y*size.X + x
-
-        var val float64
-        l := robot36Length
-        if i == 0 {
-          val = float64(ymap[mapIndex]) / 255
-          l = robot36YLength
-        } else if even {
-          val = float64(umap[mapIndex]) / 255
-        } else {
-          val = float64(vmap[mapIndex]) / 255
-        }
-
-        wr.writeValue(val, l)
-      }
-
-      if i == 0 {
-        if even {
-          wr.write(robotEvenSeparatorFrequency, robotSeparatorLength)
-        } else {
-          wr.write(robotOddSeparatorFrequency, robotSeparatorLength)
-        }
-
-        wr.write(robotPorchFrequency, robotPorchLength)
-      }
+
+    // Robot36 alternates between both chrominance components and indicates the component which
+    // follows using the frequency of its separator
+    separator := robotEvenSeparatorFrequency
+    chroma := umap
+    if y%2 != 0 {
+      separator = robotOddSeparatorFrequency
+      chroma = vmap
     }
+
+    wr.writeYUVLine(
+      yuvScan{ymap[y*size.X : (y+1)*size.X], robot36YLength, []tone{
+        {float64(separator), robotSeparatorLength},
+        {robotPorchFrequency, robotPorchLength},
+      }},
+      yuvScan{chroma[y*size.X : (y+1)*size.X], robot36Length, nil},
+    )
+
+    wr.endLine(y, size.Y)
   }
 }
 
@@ -140,34 +281,28 @@ func (enc *robotEncoder) encode72(wr *audioWriter, img image.Image) {
     wr.write(robotLineFrequency, robotLineLength)
     wr.write(robotSyncFrequency, robotSyncLength)
 
-    for i := 0; i < 3; i++ {
-      for x := 0; x < size.X; x++ {
-        y, u, v := convertYUV(img.At(x, y))
-
-        var val float64
-        l := robot72Length
-        if i == 0 {
-          val = float64(y) / 255
-          l = robot72YLength
-        } else if i == 1 {
-          val = float64(u) / 255
-        } else {
-          val = float64(v) / 255
-        }
-
-        wr.writeValue(val, l)
-      }
-
-      if i != 2 {
-        if i % 2 == 0 {
-          wr.write(robotEvenSeparatorFrequency, robotSeparatorLength)
-          wr.write(robotPorchFrequency, robotPorchLength)
-        } else {
-          wr.write(robotOddSeparatorFrequency, robotSeparatorLength)
-          wr.write(robotSyncFrequency, robotPorchLength)
-        }
-      }
+    yl, ul, vl := convertYUVLine(img, y)
+    wr.writeYUVLine(
+      yuvScan{yl, robot72YLength, robotEvenSeparator},
+      yuvScan{ul, robot72Length, robotOddSeparator},
+      yuvScan{vl, robot72Length, nil},
+    )
+
+    wr.endLine(y, size.Y)
+  }
+}
+
+func (enc *robotEncoder) encodeBW(wr *audioWriter, img image.Image, pulseLength float64) {
+  size := img.Bounds().Size()
+  for y := 0; y < size.Y; y++ {
+    wr.write(robotLineFrequency, robotBWLineLength)
+
+    for x := 0; x < size.X; x++ {
+      y, _, _ := convertYUV(img.At(x, y))
+      wr.writeValue(float64(y)/255, pulseLength)
     }
+
+    wr.endLine(y, size.Y)
   }
 }
 
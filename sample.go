@@ -0,0 +1,128 @@
+/*
+ * Copyright 2018 Johannes Donath <johannesd@torchmind.com>
+ * and other copyright owners as documented in the project's IP log.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+package sstv
+
+import (
+  "encoding/binary"
+  "math"
+)
+
+// describes the representation of individual samples within the generated signal
+type SampleFormat int
+
+const (
+  // signed 16 bit integers (default)
+  SampleInt16 SampleFormat = iota
+  // unsigned 8 bit integers which are centered around 128
+  SampleUint8
+  // signed 24 bit integers
+  SampleInt24
+  // signed 32 bit integers
+  SampleInt32
+  // 32 bit floating point numbers within the range of -1 to 1
+  SampleFloat32
+)
+
+func (f SampleFormat) String() string {
+  switch f {
+  case SampleInt16:
+    return "16 bit signed integer"
+  case SampleUint8:
+    return "8 bit unsigned integer"
+  case SampleInt24:
+    return "24 bit signed integer"
+  case SampleInt32:
+    return "32 bit signed integer"
+  case SampleFloat32:
+    return "32 bit float"
+  default:
+    return "unknown"
+  }
+}
+
+// retrieves the number of bits which make up a single sample
+func (f SampleFormat) BitDepth() int {
+  switch f {
+  case SampleUint8:
+    return 8
+  case SampleInt24:
+    return 24
+  case SampleInt32, SampleFloat32:
+    return 32
+  default:
+    return 16
+  }
+}
+
+// indicates whether samples are represented as floating point numbers
+func (f SampleFormat) IsFloat() bool {
+  return f == SampleFloat32
+}
+
+// verifies whether the format is known to this package
+func (f SampleFormat) valid() bool {
+  return f >= SampleInt16 && f <= SampleFloat32
+}
+
+// retrieves the factor and offset which map a sample within the range of -1 to 1 onto the value
+// range of this format
+func (f SampleFormat) scale() (float64, float64) {
+  switch f {
+  case SampleUint8:
+    return math.MaxInt8, math.MaxInt8 + 1
+  case SampleInt24:
+    return 1<<23 - 1, 0
+  case SampleInt32:
+    return math.MaxInt32, 0
+  case SampleFloat32:
+    return 1, 0
+  default:
+    return math.MaxInt16, 0
+  }
+}
+
+// encodes a sample within the range of -1 to 1 into its little endian representation within the
+// given buffer
+//
+// samples beyond full scale are saturated while integer samples are truncated in the same way as
+// they are when converting a buffer using audio.FloatBuffer.AsIntBuffer
+func (f SampleFormat) put(b []byte, sample float64) {
+  factor, offset := f.scale()
+  value := saturate(sample)*factor + offset
+
+  switch f {
+  case SampleUint8:
+    b[0] = uint8(value)
+  case SampleInt24:
+    v := int32(value)
+    b[0] = byte(v)
+    b[1] = byte(v >> 8)
+    b[2] = byte(v >> 16)
+  case SampleInt32:
+    binary.LittleEndian.PutUint32(b, uint32(int32(value)))
+  case SampleFloat32:
+    binary.LittleEndian.PutUint32(b, math.Float32bits(float32(value)))
+  default:
+    binary.LittleEndian.PutUint16(b, uint16(int16(value)))
+  }
+}
+
+// limits a sample to full scale in order to prevent it from wrapping around when it is converted
+// into an integer
+func saturate(sample float64) float64 {
+  return math.Max(-1, math.Min(1, sample))
+}
@@ -17,9 +17,9 @@
 package sstv
 
 import (
-  "errors"
   "github.com/go-audio/audio"
   "image"
+  "io"
 )
 
 type ScottieMode uint8
@@ -27,11 +27,15 @@ type ScottieMode uint8
 const (
   Scottie1  ScottieMode = 60
   Scottie2  ScottieMode = 56
+  Scottie3  ScottieMode = 52
+  Scottie4  ScottieMode = 48
   ScottieDx ScottieMode = 76
 )
 
 const scottie1PulseLength = .4320
 const scottie2PulseLength = .2752
+const scottie3PulseLength = .4320
+const scottie4PulseLength = .2752
 const scottieDxPulseLength = 1.08
 
 const scottieSyncLength = 9
@@ -42,18 +46,29 @@ const scottySeparatorFrequency = 1500
 
 // provides a Scottie implementation as designed by Eddie Murphy
 //
-// this implementation encodes RGB images into 240 lines
+// this implementation encodes RGB images into 256 lines (or 128 lines when using the half-height
+// Scottie3 and Scottie4 modes)
 type scottieEncoder struct {
   mode   ScottieMode
   format *audio.Format
 }
 
 // creates a new Scottie compatible image encoder
-func NewScottie(mode ScottieMode, format *audio.Format) Encoder {
+func NewScottie(mode ScottieMode, format *audio.Format) (Encoder, error) {
+  switch mode {
+  case Scottie1, Scottie2, Scottie3, Scottie4, ScottieDx:
+  default:
+    return nil, ErrIllegalMode
+  }
+
+  if err := validateFormat(format, whiteFrequency); err != nil {
+    return nil, err
+  }
+
   return &scottieEncoder{
     mode:   mode,
     format: format,
-  }
+  }, nil
 }
 
 func (enc *scottieEncoder) Vis() uint8 {
@@ -61,23 +76,81 @@ func (enc *scottieEncoder) Vis() uint8 {
 }
 
 func (enc *scottieEncoder) Resolution() image.Rectangle {
-  return image.Rect(0, 0, 320, 256)
+  switch enc.mode {
+  case Scottie3, Scottie4:
+    return image.Rect(0, 0, 320, 128)
+  default:
+    return image.Rect(0, 0, 320, 256)
+  }
+}
+
+func (enc *scottieEncoder) Info() ModeInfo {
+  res := enc.Resolution()
+  lineLength := 3*(scottySeparatorLength+float64(res.Dx())*enc.pulseLength()) + scottieSyncLength
+
+  // the first line is preceded by an additional sync pulse
+  return newModeInfo(
+    enc.name(),
+    ColorRGB,
+    []Channel{ChannelGreen, ChannelBlue, ChannelRed},
+    blackFrequency,
+    whiteFrequency,
+    tonesLength(calibrationHeader)+visLength+scottieSyncLength,
+    lineLength,
+    res.Dy(),
+  )
+}
+
+// retrieves the human readable name of this mode
+func (enc *scottieEncoder) name() string {
+  switch enc.mode {
+  case Scottie2:
+    return "Scottie 2"
+  case Scottie3:
+    return "Scottie 3"
+  case Scottie4:
+    return "Scottie 4"
+  case ScottieDx:
+    return "Scottie DX"
+  default:
+    return "Scottie 1"
+  }
 }
 
-func (enc *scottieEncoder) Encode(image image.Image) *audio.FloatBuffer {
-  var pulseLength float64
+// retrieves the length of a single pixel within this mode
+func (enc *scottieEncoder) pulseLength() float64 {
   switch enc.mode {
   case Scottie1:
-    pulseLength = scottie1PulseLength
+    return scottie1PulseLength
   case Scottie2:
-    pulseLength = scottie2PulseLength
+    return scottie2PulseLength
+  case Scottie3:
+    return scottie3PulseLength
+  case Scottie4:
+    return scottie4PulseLength
   case ScottieDx:
-    pulseLength = scottieDxPulseLength
+    return scottieDxPulseLength
   default:
-    panic(errors.New("illegal encoding mode"))
+    return 0
   }
+}
+
+func (enc *scottieEncoder) Encode(img image.Image) *audio.FloatBuffer {
+  buf, _ := encodeBuffer(enc, img, EncodeOptions{})
+  return buf
+}
+
+func (enc *scottieEncoder) EncodeTo(img image.Image, w io.Writer) error {
+  return encodeStream(enc, img, w, EncodeOptions{})
+}
+
+func (enc *scottieEncoder) audioFormat() *audio.Format {
+  return enc.format
+}
+
+func (enc *scottieEncoder) encode(wr *audioWriter, image image.Image) {
+  pulseLength := enc.pulseLength()
 
-  wr := newWriter(enc.format)
   wr.writeHeader()
   wr.writeVis(uint8(enc.mode))
 
@@ -109,7 +182,7 @@ func (enc *scottieEncoder) Encode(image image.Image) *audio.FloatBuffer {
         wr.write(scottieSyncFrequency, scottieSyncLength)
       }
     }
-  }
 
-  return wr.buf
+    wr.endLine(y, size.Y)
+  }
 }
@@ -0,0 +1,110 @@
+/*
+ * Copyright 2018 Johannes Donath <johannesd@torchmind.com>
+ * and other copyright owners as documented in the project's IP log.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+package sstv
+
+import "math"
+
+// smoothes the instantaneous frequency of a signal in order to replace the instant transitions
+// between tones with raised cosine ramps
+//
+// the frequency of every generated sample is convolved with a half sine kernel (the derivative of
+// the raised cosine) which is centered on the sample and thus every step within the signal turns
+// into a ramp which is centered on the original position of the step. As a result, the timing of
+// the transmission is not affected by the shaping
+type frequencyShaper struct {
+  kernel []float64
+  // input frequencies starting at the first sample which is still covered by the kernel
+  buf []float64
+  // indicates whether the buffer has been padded with the initial frequency
+  started bool
+}
+
+// creates a new shaper which generates ramps of the given length (in samples)
+//
+// returns nil when the length is too short to have any effect on the signal
+func newFrequencyShaper(length int) *frequencyShaper {
+  half := length / 2
+  if half < 1 {
+    return nil
+  }
+
+  kernel := make([]float64, 2*half+1)
+  sum := 0.0
+  for i := range kernel {
+    kernel[i] = math.Sin(math.Pi * (float64(i) + .5) / float64(len(kernel)))
+    sum += kernel[i]
+  }
+  for i := range kernel {
+    kernel[i] /= sum
+  }
+
+  return &frequencyShaper{
+    kernel: kernel,
+  }
+}
+
+// appends the given number of samples at a fixed frequency and returns the instantaneous
+// frequencies of all samples which have been fully shaped as a result
+//
+// samples near the end of the signal are delayed until the following segment is known and are
+// returned by subsequent calls (or by drain)
+func (s *frequencyShaper) shape(frequency float64, samples int) []float64 {
+  if !s.started {
+    s.pad(frequency)
+    s.started = true
+  }
+  for i := 0; i < samples; i++ {
+    s.buf = append(s.buf, frequency)
+  }
+  return s.convolve()
+}
+
+// returns the instantaneous frequencies of all remaining samples assuming that the final
+// frequency is held beyond the end of the signal
+func (s *frequencyShaper) drain() []float64 {
+  if len(s.buf) == 0 {
+    return nil
+  }
+  s.pad(s.buf[len(s.buf)-1])
+  return s.convolve()
+}
+
+// extends the buffer by half the kernel length using the given frequency
+func (s *frequencyShaper) pad(frequency float64) {
+  for i := 0; i < len(s.kernel)/2; i++ {
+    s.buf = append(s.buf, frequency)
+  }
+}
+
+// computes all samples for which the kernel is fully covered by the buffer and discards the
+// input samples which are no longer required
+func (s *frequencyShaper) convolve() []float64 {
+  n := len(s.buf) - len(s.kernel) + 1
+  if n <= 0 {
+    return nil
+  }
+
+  values := make([]float64, n)
+  for i := range values {
+    for j, k := range s.kernel {
+      values[i] += k * s.buf[i+j]
+    }
+  }
+
+  s.buf = append(s.buf[:0], s.buf[n:]...)
+  return values
+}
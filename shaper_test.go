@@ -0,0 +1,82 @@
+/*
+ * Copyright 2018 Johannes Donath <johannesd@torchmind.com>
+ * and other copyright owners as documented in the project's IP log.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+package sstv
+
+import (
+  "github.com/go-audio/audio"
+  "image"
+  "math"
+  "testing"
+  "time"
+)
+
+func TestFrequencyShaperLength(t *testing.T) {
+  for _, length := range []int{2, 13, 44, 101} {
+    s := newFrequencyShaper(length)
+
+    var in, out int
+    for i, samples := range []int{0, 1, 7, 300, 2, 0, 55, 1000, 3} {
+      in += samples
+      out += len(s.shape(float64(1500+100*i), samples))
+    }
+    out += len(s.drain())
+
+    if in != out {
+      t.Errorf("length %d: shaped %d samples (expected %d)", length, out, in)
+    }
+  }
+}
+
+func TestFrequencyShaperRamp(t *testing.T) {
+  s := newFrequencyShaper(41)
+
+  values := s.shape(1500, 100)
+  values = append(values, s.shape(2300, 100)...)
+  values = append(values, s.drain()...)
+
+  // the step is replaced by a monotonic ramp which is centered on its original position and
+  // settles at both frequencies outside of the kernel
+  for i := 1; i < len(values); i++ {
+    if values[i] < values[i-1]-1e-9 {
+      t.Fatalf("ramp is not monotonic at sample %d", i)
+    }
+  }
+  if math.Abs(values[79]-1500) > 1e-9 || math.Abs(values[120]-2300) > 1e-9 {
+    t.Errorf("ramp exceeds kernel: %f / %f", values[79], values[120])
+  }
+  if math.Abs(values[99]+values[100]-3800) > 1e-6 {
+    t.Errorf("ramp is not centered on the step: %f / %f", values[99], values[100])
+  }
+}
+
+func TestTransitionPreservesLength(t *testing.T) {
+  enc, err := NewRobot(Robot36, &audio.Format{NumChannels: 1, SampleRate: 11025})
+  if err != nil {
+    t.Fatal(err)
+  }
+  img := image.NewRGBA(enc.Resolution())
+
+  plain := enc.Encode(img)
+  shaped, err := EncodeWithOptions(enc, img, EncodeOptions{Transition: 500 * time.Microsecond})
+  if err != nil {
+    t.Fatal(err)
+  }
+
+  if len(plain.Data) != len(shaped.Data) {
+    t.Errorf("encoded %d samples (expected %d)", len(shaped.Data), len(plain.Data))
+  }
+}
@@ -0,0 +1,159 @@
+/*
+ * Copyright 2018 Johannes Donath <johannesd@torchmind.com>
+ * and other copyright owners as documented in the project's IP log.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+package sstv
+
+import (
+  "bufio"
+  "github.com/go-audio/audio"
+  "io"
+  "math"
+)
+
+// receives the samples which are generated by an audio writer
+type sampleSink interface {
+  // consumes a sequence of generated samples within the range of -1 to 1
+  writeSamples(samples []float64) error
+  // writes any remaining buffered samples to the underlying destination
+  flush() error
+}
+
+// collects all generated samples within an in-memory buffer
+type bufferSink struct {
+  buf    *audio.FloatBuffer
+  scale  float64
+  offset float64
+}
+
+// creates a new sink which collects samples within a buffer of the given format after mapping
+// them using the given scale and offset
+func newBufferSink(format *audio.Format, scale float64, offset float64) *bufferSink {
+  return &bufferSink{
+    buf: &audio.FloatBuffer{
+      Format: format,
+      Data:   make([]float64, 0),
+    },
+    scale:  scale,
+    offset: offset,
+  }
+}
+
+func (s *bufferSink) writeSamples(samples []float64) error {
+  for _, sample := range samples {
+    s.buf.Data = append(s.buf.Data, saturate(sample)*s.scale+s.offset)
+  }
+  return nil
+}
+
+func (s *bufferSink) flush() error {
+  return nil
+}
+
+// encodes all generated samples as raw little endian PCM onto an arbitrary stream
+type streamSink struct {
+  w       *bufio.Writer
+  format  SampleFormat
+  scratch []byte
+}
+
+// creates a new sink which writes samples in the given format onto the given stream
+func newStreamSink(w io.Writer, format SampleFormat) *streamSink {
+  return &streamSink{
+    w:      bufio.NewWriter(w),
+    format: format,
+  }
+}
+
+func (s *streamSink) writeSamples(samples []float64) error {
+  size := s.format.BitDepth() / 8
+  if cap(s.scratch) < len(samples)*size {
+    s.scratch = make([]byte, len(samples)*size)
+  }
+  s.scratch = s.scratch[:len(samples)*size]
+
+  for i, sample := range samples {
+    s.format.put(s.scratch[i*size:], sample)
+  }
+
+  _, err := s.w.Write(s.scratch)
+  return err
+}
+
+func (s *streamSink) flush() error {
+  return s.w.Flush()
+}
+
+// applies raised cosine fades to the start and end of the samples which are passed to another
+// sink
+//
+// as the end of the signal is not known until the sink is flushed, the samples which are covered
+// by the fade out are held back until then
+type fadeSink struct {
+  out     sampleSink
+  fadeIn  int
+  fadeOut int
+  // total number of samples which have been received so far
+  samples int
+  // samples which are potentially covered by the fade out
+  tail []float64
+}
+
+// creates a new sink which fades the given number of samples at the start and end of the signal
+// before passing them to another sink
+func newFadeSink(out sampleSink, fadeIn int, fadeOut int) *fadeSink {
+  return &fadeSink{
+    out:     out,
+    fadeIn:  fadeIn,
+    fadeOut: fadeOut,
+  }
+}
+
+func (s *fadeSink) writeSamples(samples []float64) error {
+  for _, sample := range samples {
+    if s.samples < s.fadeIn {
+      sample *= fadeGain(s.samples, s.fadeIn)
+    }
+    s.tail = append(s.tail, sample)
+    s.samples++
+  }
+
+  if len(s.tail) <= s.fadeOut {
+    return nil
+  }
+
+  n := len(s.tail) - s.fadeOut
+  err := s.out.writeSamples(s.tail[:n])
+  s.tail = append(s.tail[:0], s.tail[n:]...)
+  return err
+}
+
+func (s *fadeSink) flush() error {
+  for i := range s.tail {
+    s.tail[i] *= fadeGain(len(s.tail)-i-1, len(s.tail))
+  }
+
+  if err := s.out.writeSamples(s.tail); err != nil {
+    return err
+  }
+  s.tail = nil
+  return s.out.flush()
+}
+
+// computes the gain of a given sample within a raised cosine fade of the given length (where the
+// first sample is silent)
+func fadeGain(sample int, length int) float64 {
+  return .5 - .5*math.Cos(math.Pi*float64(sample)/float64(length))
+}
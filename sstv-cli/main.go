@@ -27,32 +27,103 @@ import (
   _ "image/jpeg"
   _ "image/png"
   "os"
+  "time"
 )
 
 var audioFormat = audio.FormatMono44100
 
+// maps the short mode flags onto their respective registered mode names
+var modeFlags = []struct {
+  flag  string
+  mode  string
+  usage string
+}{
+  {"avt24", "avt24", "uses AVT encoding in AVT24 mode"},
+  {"avt90", "avt90", "uses AVT encoding in AVT90 mode"},
+  {"avt94", "avt94", "uses AVT encoding in AVT94 mode"},
+  {"avt125", "avt125", "uses AVT encoding in AVT125 (B/W) mode"},
+  {"avt188", "avt188", "uses AVT encoding in AVT188 mode"},
+  {"fax480", "fax480", "uses FAX480 encoding"},
+  {"m1", "martin1", "uses Martin encoding in M1 mode"},
+  {"m2", "martin2", "uses Martin encoding in M2 mode"},
+  {"m3", "martin3", "uses Martin encoding in M3 mode"},
+  {"m4", "martin4", "uses Martin encoding in M4 mode"},
+  {"mc110", "mc110", "uses narrow MMSSTV MC encoding in MC110 mode"},
+  {"mc140", "mc140", "uses narrow MMSSTV MC encoding in MC140 mode"},
+  {"mc180", "mc180", "uses narrow MMSSTV MC encoding in MC180 mode"},
+  {"ml180", "ml180", "uses MMSSTV ML encoding in ML180 mode"},
+  {"ml240", "ml240", "uses MMSSTV ML encoding in ML240 mode"},
+  {"ml280", "ml280", "uses MMSSTV ML encoding in ML280 mode"},
+  {"ml320", "ml320", "uses MMSSTV ML encoding in ML320 mode"},
+  {"mp73", "mp73", "uses MMSSTV MP encoding in MP73 mode"},
+  {"mp115", "mp115", "uses MMSSTV MP encoding in MP115 mode"},
+  {"mp140", "mp140", "uses MMSSTV MP encoding in MP140 mode"},
+  {"mp175", "mp175", "uses MMSSTV MP encoding in MP175 mode"},
+  {"mr73", "mr73", "uses MMSSTV MR encoding in MR73 mode"},
+  {"mr90", "mr90", "uses MMSSTV MR encoding in MR90 mode"},
+  {"mr115", "mr115", "uses MMSSTV MR encoding in MR115 mode"},
+  {"mr140", "mr140", "uses MMSSTV MR encoding in MR140 mode"},
+  {"mr175", "mr175", "uses MMSSTV MR encoding in MR175 mode"},
+  {"mn73", "mn73", "uses narrow MMSSTV MN encoding in MN73 mode"},
+  {"mn110", "mn110", "uses narrow MMSSTV MN encoding in MN110 mode"},
+  {"mn140", "mn140", "uses narrow MMSSTV MN encoding in MN140 mode"},
+  {"p3", "pasokon3", "uses Pasokon (\"P\") in P3 mode"},
+  {"p5", "pasokon5", "uses Pasokon (\"P\") in P5 mode"},
+  {"p7", "pasokon7", "uses Pasokon (\"P\") in P7 mode"},
+  {"pd50", "pd50", "uses PD encoding in PD50 mode"},
+  {"pd90", "pd90", "uses PD encoding in PD90 mode"},
+  {"pd120", "pd120", "uses PD encoding in PD120 mode"},
+  {"pd160", "pd160", "uses PD encoding in PD160 mode"},
+  {"pd180", "pd180", "uses PD encoding in PD180 mode"},
+  {"pd240", "pd240", "uses PD encoding in PD240 mode"},
+  {"pd290", "pd290", "uses PD encoding in PD290 mode"},
+  {"r24", "robot24", "uses Robot encoding in 24 mode"},
+  {"r36", "robot36", "uses Robot encoding in 36 mode"},
+  {"r72", "robot72", "uses Robot encoding in 72 mode"},
+  {"r8bw", "robot8bw", "uses Robot encoding in 8 B/W mode"},
+  {"r12bw", "robot12bw", "uses Robot encoding in 12 B/W mode"},
+  {"r24bw", "robot24bw", "uses Robot encoding in 24 B/W mode"},
+  {"r36bw", "robot36bw", "uses Robot encoding in 36 B/W mode"},
+  {"s1", "scottie1", "uses Scottie encoding in S1 mode"},
+  {"s2", "scottie2", "uses Scottie encoding in S2 mode"},
+  {"s3", "scottie3", "uses Scottie encoding in S3 mode"},
+  {"s4", "scottie4", "uses Scottie encoding in S4 mode"},
+  {"sdx", "scottiedx", "uses Scottie encoding in DX mode"},
+  {"wrsc2-30", "sc2-30", "uses Wrasse encoding in SC2-30 mode"},
+  {"wrsc2-60", "sc2-60", "uses Wrasse encoding in SC2-60 mode"},
+  {"wrsc2-120", "sc2-120", "uses Wrasse encoding in SC2-120 mode"},
+  {"wrsc2-180", "sc2-180", "uses Wrasse encoding in SC2-180 mode"},
+}
+
 func main() {
   var flagHelp bool
-  var flagSampleRate int
-  var flagMartin1, flagMartin2 bool
-  var flagPasokon3, flagPasokon5, flagPasokon7 bool
-  var flagRobot36, flagRobot72 bool
-  var flagScottie1, flagScottie2, flagScottieDx bool
-  var flagWrasseSC2180 bool
+  var flagListModes bool
+  var flagRaw, flagFloat bool
+  var flagSampleRate, flagReferenceRate, flagBitDepth, flagChannels, flagOutputChannel int
+  var flagMode, flagModeFile string
+  var flagTransition, flagFadeIn, flagFadeOut time.Duration
+  var flagLevel float64
 
   flag.BoolVar(&flagHelp, "help", false, "displays this help message")
+  flag.BoolVar(&flagListModes, "list-modes", false, "displays a list of all available modes")
+  flag.BoolVar(&flagRaw, "raw", false, "streams raw little endian PCM samples instead of generating a WAV file")
   flag.IntVar(&flagSampleRate, "sample-rate", 44100, "specifies the sample rate (defaults to 19200 Hz)")
-  flag.BoolVar(&flagMartin1, "m1", false, "uses Martin encoding in M1 mode")
-  flag.BoolVar(&flagMartin2, "m2", false, "uses Martin encoding in M2 mode")
-  flag.BoolVar(&flagPasokon3, "p3", false, "uses Pasokon (\"P\") in P3 mode")
-  flag.BoolVar(&flagPasokon5, "p5", false, "uses Pasokon (\"P\") in P3 mode")
-  flag.BoolVar(&flagPasokon7, "p7", false, "uses Pasokon (\"P\") in P3 mode")
-  flag.BoolVar(&flagRobot36, "r36", false, "uses Robot encoding in 36 mode")
-  flag.BoolVar(&flagRobot72, "r72", false, "uses Robot encoding in 72 mode")
-  flag.BoolVar(&flagScottie1, "s1", false, "uses Scottie encoding in S1 mode")
-  flag.BoolVar(&flagScottie2, "s2", false, "uses Scottie encoding in S2 mode")
-  flag.BoolVar(&flagScottieDx, "sdx", false, "uses Scottie encoding in DX mode")
-  flag.BoolVar(&flagWrasseSC2180, "wrsc2-180", false, "uses Wrasse encoding in SC2-180 mode")
+  flag.IntVar(&flagReferenceRate, "reference-rate", 0, "generates the signal at the specified sample rate (such as 96000) and resamples it to the output sample rate")
+  flag.IntVar(&flagBitDepth, "bit-depth", sstv.BitDepth, "specifies the bit depth of integer samples (8, 16, 24 or 32)")
+  flag.IntVar(&flagChannels, "channels", 1, "specifies the number of output channels")
+  flag.IntVar(&flagOutputChannel, "output-channel", -1, "places the signal on the specified (zero based) channel only (defaults to all channels)")
+  flag.BoolVar(&flagFloat, "float", false, "generates 32 bit floating point samples (requires -raw)")
+  flag.StringVar(&flagMode, "mode", "", "selects a mode by name (such as robot36)")
+  flag.StringVar(&flagModeFile, "mode-file", "", "loads custom mode definitions from the specified JSON file")
+  flag.Float64Var(&flagLevel, "level", 0, "specifies the peak level of the signal in dBFS (such as -6)")
+  flag.DurationVar(&flagFadeIn, "fade-in", 0, "fades in the signal over the specified duration (such as 10ms)")
+  flag.DurationVar(&flagFadeOut, "fade-out", 0, "fades out the signal over the specified duration (such as 10ms)")
+  flag.DurationVar(&flagTransition, "transition", 0, "smoothes transitions between tones over the specified duration (such as 300us) in order to reduce the signal bandwidth")
+
+  modeFlagValues := make([]bool, len(modeFlags))
+  for i, f := range modeFlags {
+    flag.BoolVar(&modeFlagValues[i], f.flag, false, f.usage)
+  }
 
   flag.Parse()
 
@@ -61,56 +132,65 @@ func main() {
     return
   }
 
-  if flag.NArg() != 2 {
-    printHelp()
-    os.Exit(1)
-  }
-
-  format := &audio.Format{
-    NumChannels: 1,
-    SampleRate:  flagSampleRate,
-  }
+  if flagModeFile != "" {
+    defs, err := sstv.LoadModeFile(flagModeFile)
+    if err != nil {
+      fmt.Printf("failed to load mode file: %s\n", err)
+      os.Exit(2)
+    }
 
-  var tv sstv.Encoder
-  if flagMartin1 || flagMartin2 {
-    mode := sstv.Martin1
-    if flagMartin2 {
-      mode = sstv.Martin2
+    for _, def := range defs {
+      sstv.RegisterDefinition(def)
     }
 
-    tv = sstv.NewMartin(mode, format)
-  } else if flagPasokon3 || flagPasokon5 || flagPasokon7 {
-    mode := sstv.Pasokon3
-    if flagPasokon5 {
-      mode = sstv.Pasokon5
-    } else if flagPasokon7 {
-      mode = sstv.Pasokon7
+    if flagMode == "" && len(defs) != 0 {
+      flagMode = defs[0].Name
     }
+  }
 
-    tv = sstv.NewPasokon(mode, format)
-  } else if flagRobot36 || flagRobot72 {
-    mode := sstv.Robot36
-    if flagRobot72 {
-      mode = sstv.Robot72
+  if flagListModes {
+    for _, mode := range sstv.Modes() {
+      fmt.Printf("%-12s VIS 0x%02x\n", mode.Name, mode.Vis)
     }
+    return
+  }
 
-    tv = sstv.NewRobot(mode, format)
-  } else if flagScottie1 || flagScottie2 || flagScottieDx {
-    mode := sstv.Scottie1
-    if flagScottie2 {
-      mode = sstv.Scottie2
-    } else if flagScottieDx {
-      mode = sstv.ScottieDx
+  if flag.NArg() != 2 {
+    printHelp()
+    os.Exit(1)
+  }
+
+  for i, f := range modeFlags {
+    if modeFlagValues[i] {
+      flagMode = f.mode
+      break
     }
+  }
 
-    tv = sstv.NewScottie(mode, format)
-  } else if flagWrasseSC2180 {
-    mode := sstv.WrasseSC2180
+  mode, ok := sstv.ModeByName(flagMode)
+  if !ok {
+    fmt.Printf("no such mode: %s\n", flagMode)
+    os.Exit(1)
+  }
 
-    tv = sstv.NewWrasse(mode, format)
+  format := &audio.Format{
+    NumChannels: flagChannels,
+    SampleRate:  flagSampleRate,
   }
 
-  fmt.Printf("==> using VIS 0x%02x\n", tv.Vis())
+  tv, err := mode.New(format)
+  if err != nil {
+    fmt.Printf("failed to create encoder: %s\n", err)
+    os.Exit(1)
+  }
+  if ext, ok := tv.(sstv.ExtendedEncoder); ok {
+    fmt.Printf("==> using extended VIS 0x%04x\n", ext.ExtendedVis())
+  } else {
+    fmt.Printf("==> using VIS 0x%02x\n", tv.Vis())
+  }
+
+  info := tv.Info()
+  fmt.Printf("==> encoding %s (%s) in %s\n", info.Name, info.ColorModel, info.Duration)
 
   var img image.Image
   fmt.Print("loading file ... ")
@@ -136,15 +216,71 @@ func main() {
     fmt.Print("skipped\n")
   }
 
+  opts := sstv.EncodeOptions{
+    Transition:    flagTransition,
+    Level:         flagLevel,
+    FadeIn:        flagFadeIn,
+    FadeOut:       flagFadeOut,
+    ReferenceRate: flagReferenceRate,
+  }
+
+  if flagOutputChannel >= 0 {
+    opts.ChannelLayout = sstv.ChannelSingle
+    opts.OutputChannel = flagOutputChannel
+  }
+
+  switch {
+  case flagFloat:
+    opts.SampleFormat = sstv.SampleFloat32
+  case flagBitDepth == 8:
+    opts.SampleFormat = sstv.SampleUint8
+  case flagBitDepth == 16:
+    opts.SampleFormat = sstv.SampleInt16
+  case flagBitDepth == 24:
+    opts.SampleFormat = sstv.SampleInt24
+  case flagBitDepth == 32:
+    opts.SampleFormat = sstv.SampleInt32
+  default:
+    fmt.Printf("unsupported bit depth: %d\n", flagBitDepth)
+    os.Exit(1)
+  }
+
+  if flagRaw {
+    fmt.Print("streaming ... ")
+    if wr, err := os.OpenFile(flag.Arg(1), os.O_CREATE|os.O_WRONLY|os.O_TRUNC, os.ModePerm); err == nil {
+      defer wr.Close()
+
+      if err = sstv.EncodeToWithOptions(tv, img, wr, opts); err != nil {
+        fmt.Printf("failed: %s\n", err)
+        os.Exit(2)
+      }
+
+      fmt.Print("ok\n")
+    } else {
+      fmt.Printf("failed: %s\n", err)
+      os.Exit(2)
+    }
+    return
+  }
+
+  if opts.SampleFormat.IsFloat() {
+    fmt.Print("floating point samples may only be generated in combination with -raw\n")
+    os.Exit(1)
+  }
+
   fmt.Print("generating ... ")
-  buf := tv.Encode(img)
+  buf, err := sstv.EncodeWithOptions(tv, img, opts)
+  if err != nil {
+    fmt.Printf("failed: %s\n", err)
+    os.Exit(2)
+  }
   fmt.Print("ok\n")
 
   fmt.Print("encoding ... ")
   if wr, err := os.OpenFile(flag.Arg(1), os.O_CREATE|os.O_WRONLY|os.O_TRUNC, os.ModePerm); err == nil {
     defer wr.Close()
 
-    enc := wav.NewEncoder(wr, flagSampleRate, sstv.BitDepth, 1, 1)
+    enc := wav.NewEncoder(wr, flagSampleRate, opts.SampleFormat.BitDepth(), flagChannels, 1)
     defer enc.Close()
 
     if err = enc.Write(buf.AsIntBuffer()); err != nil {
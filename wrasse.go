@@ -19,15 +19,24 @@ package sstv
 import (
   "github.com/go-audio/audio"
   "image"
+  "io"
 )
 
 type WrasseMode uint8
 
 const (
+  WrasseSC230  WrasseMode = 51
+  WrasseSC260  WrasseMode = 59
+  WrasseSC2120 WrasseMode = 63
   WrasseSC2180 WrasseMode = 55
 )
 
-const wrassePulseLength = .7344
+const (
+  wrasseSC230PulseLength  = .2444
+  wrasseSC260PulseLength  = .2444
+  wrasseSC2120PulseLength = .4890
+  wrasseSC2180PulseLength = .7344
+)
 
 const (
   wrasseLineFrequency = 1200
@@ -39,18 +48,28 @@ const (
 
 // provides a Wrasse implementation
 //
-// this implementation encodes RGB images into 256 lines
+// this implementation encodes RGB images into 256 lines (or 128 lines when using SC2-30)
 type wrasseEncoder struct {
   mode   WrasseMode
   format *audio.Format
 }
 
 // creates a new Wrasse compatible image encoder
-func NewWrasse(mode WrasseMode, format *audio.Format) Encoder {
+func NewWrasse(mode WrasseMode, format *audio.Format) (Encoder, error) {
+  switch mode {
+  case WrasseSC230, WrasseSC260, WrasseSC2120, WrasseSC2180:
+  default:
+    return nil, ErrIllegalMode
+  }
+
+  if err := validateFormat(format, whiteFrequency); err != nil {
+    return nil, err
+  }
+
   return &wrasseEncoder{
     mode:   mode,
     format: format,
-  }
+  }, nil
 }
 
 func (enc *wrasseEncoder) Vis() uint8 {
@@ -58,11 +77,73 @@ func (enc *wrasseEncoder) Vis() uint8 {
 }
 
 func (enc *wrasseEncoder) Resolution() image.Rectangle {
-  return image.Rect(0, 0, 320, 256)
+  switch enc.mode {
+  case WrasseSC230:
+    return image.Rect(0, 0, 320, 128)
+  default:
+    return image.Rect(0, 0, 320, 256)
+  }
+}
+
+func (enc *wrasseEncoder) Info() ModeInfo {
+  res := enc.Resolution()
+
+  var name string
+  switch enc.mode {
+  case WrasseSC230:
+    name = "Wrasse SC2-30"
+  case WrasseSC260:
+    name = "Wrasse SC2-60"
+  case WrasseSC2120:
+    name = "Wrasse SC2-120"
+  default:
+    name = "Wrasse SC2-180"
+  }
+
+  return newModeInfo(
+    name,
+    ColorRGB,
+    []Channel{ChannelGreen, ChannelBlue, ChannelRed},
+    blackFrequency,
+    whiteFrequency,
+    tonesLength(calibrationHeader)+visLength,
+    wrasseLineLength+wrasseSyncLength+3*float64(res.Dx())*enc.pulseLength(),
+    res.Dy(),
+  )
+}
+
+// retrieves the length of a single pixel within this mode
+func (enc *wrasseEncoder) pulseLength() float64 {
+  switch enc.mode {
+  case WrasseSC230:
+    return wrasseSC230PulseLength
+  case WrasseSC260:
+    return wrasseSC260PulseLength
+  case WrasseSC2120:
+    return wrasseSC2120PulseLength
+  case WrasseSC2180:
+    return wrasseSC2180PulseLength
+  default:
+    return 0
+  }
 }
 
 func (enc *wrasseEncoder) Encode(img image.Image) *audio.FloatBuffer {
-  wr := newWriter(enc.format)
+  buf, _ := encodeBuffer(enc, img, EncodeOptions{})
+  return buf
+}
+
+func (enc *wrasseEncoder) EncodeTo(img image.Image, w io.Writer) error {
+  return encodeStream(enc, img, w, EncodeOptions{})
+}
+
+func (enc *wrasseEncoder) audioFormat() *audio.Format {
+  return enc.format
+}
+
+func (enc *wrasseEncoder) encode(wr *audioWriter, img image.Image) {
+  pulseLength := enc.pulseLength()
+
   wr.writeHeader()
   wr.writeVis(uint8(enc.mode))
 
@@ -84,10 +165,10 @@ func (enc *wrasseEncoder) Encode(img image.Image) *audio.FloatBuffer {
         case 2:
           val = r
         }
-        wr.writeValue(val, wrassePulseLength)
+        wr.writeValue(val, pulseLength)
       }
     }
-  }
 
-  return wr.buf
+    wr.endLine(y, size.Y)
+  }
 }
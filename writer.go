@@ -17,9 +17,13 @@
 package sstv
 
 import (
+  "context"
   "github.com/go-audio/audio"
+  "image"
+  "math"
 )
 
+// the bit depth of samples which are generated unless a different sample format is requested
 const BitDepth = 16
 
 const headerFrequency = 1900
@@ -32,46 +36,196 @@ const bitLength = 30
 const trueFrequency = 1100
 const falseFrequency = 1300
 
+// the digital AVT header as described in J.L. Barber (N7CXI), "Proposal for SSTV Mode
+// Specifications" (Dayton SSTV forum, 2000): bits are transmitted at 1900 Hz +/- 200 Hz
+const avtFrameCount = 32
+const avtBitLength = 9.7656
+const avtTrueFrequency = 2100
+const avtFalseFrequency = 1700
+
 const blackFrequency = 1500
 const whiteFrequency = 2300
 
+const narrowBlackFrequency = 2044
+const narrowWhiteFrequency = 2300
+
+// offset (in Hz) by which the header pause and VIS code are shifted in narrow modes in order to
+// keep the entire transmission within the narrow band (1900 - 2300 Hz)
+const narrowVisOffset = 900
+
+// represents a single fixed frequency segment within a transmission
+type tone struct {
+  frequency float64
+  length    float64
+}
+
+// represents the scan of a single luminance or chrominance component within a YUV line as well as
+// the separator tones which follow it
+type yuvScan struct {
+  values      []byte
+  pulseLength float64
+  separator   []tone
+}
+
+// the standard calibration header which precedes the VIS code in most modes
+var calibrationHeader = []tone{
+  {headerFrequency, headerLength},
+  {headerPauseFrequency, headerPauseLength},
+  {headerFrequency, headerLength},
+}
+
+// the calibration header which precedes the VIS code in narrow modes
+var narrowCalibrationHeader = []tone{
+  {headerFrequency, headerLength},
+  {headerPauseFrequency + narrowVisOffset, headerPauseLength},
+  {headerFrequency, headerLength},
+}
+
 type audioWriter struct {
-  gen *oscillator
-  buf *audio.FloatBuffer
+  gen   *oscillator
+  out   sampleSink
+  err   error
+  black float64
+  white float64
+  // offset (in Hz) which is applied to all tones of the VIS code
+  visOffset float64
+
+  // ideal position (in milliseconds) at which the previously written segment ends
+  position float64
+  // total number of samples which have been written so far
+  samples int
+
+  ctx      context.Context
+  progress ProgressFunc
+  // replaces the instant transitions between tones with ramps (nil if disabled)
+  shaper *frequencyShaper
 }
 
-func newWriter(format *audio.Format) *audioWriter {
-  return &audioWriter{
-    gen: newOscillator(format.SampleRate, float64(audio.IntMaxSignedValue(BitDepth))),
-    buf: &audio.FloatBuffer{
-      Format: format,
-      Data:   make([]float64, 0),
-    },
+// creates a new writer which passes its samples to the given sink and encodes pixel values within
+// the standard frequency range
+//
+// when a reference rate has been requested, the signal is generated at the reference rate and
+// resampled to the sample rate of the format before it is passed to the sink
+func newWriter(format *audio.Format, out sampleSink, opts EncodeOptions) *audioWriter {
+  if format.NumChannels > 1 {
+    out = newChannelSink(out, format.NumChannels, opts.ChannelLayout, opts.OutputChannel)
+  }
+  sampleRate := format.SampleRate
+  if opts.ReferenceRate != 0 && opts.ReferenceRate != format.SampleRate {
+    sampleRate = opts.ReferenceRate
+    out = newResampler(out, sampleRate, format.SampleRate)
+  }
+  fadeIn := durationSamples(opts.FadeIn, sampleRate)
+  fadeOut := durationSamples(opts.FadeOut, sampleRate)
+  if fadeIn > 0 || fadeOut > 0 {
+    out = newFadeSink(out, fadeIn, fadeOut)
+  }
+
+  wr := &audioWriter{
+    gen:      newOscillator(sampleRate, opts.gain()),
+    out:      out,
+    black:    blackFrequency,
+    white:    whiteFrequency,
+    ctx:      opts.Context,
+    progress: opts.Progress,
+    shaper:   newFrequencyShaper(durationSamples(opts.Transition, sampleRate)),
+  }
+  if wr.ctx != nil {
+    wr.err = wr.ctx.Err()
   }
+  return wr
+}
+
+// shifts the tones of subsequently written VIS codes by the given offset (in Hz)
+func (wr *audioWriter) setVisOffset(offset float64) {
+  wr.visOffset = offset
 }
 
-// appends a signal with the given frequency and length to the buffer
+// adjusts the frequency range in which pixel values are encoded
+func (wr *audioWriter) setFrequencyRange(black float64, white float64) {
+  wr.black = black
+  wr.white = white
+}
+
+// appends a signal with the given frequency and length to the output
+//
+// segments are aligned to the ideal position within the transmission rather than being rounded
+// individually in order to prevent rounding errors from accumulating over the course of the
+// image (which would otherwise result in slanted images)
+//
+// once the output has failed, all subsequent segments are discarded and the error is reported
+// when the writer is closed
 func (wr *audioWriter) write(freq float64, length float64) {
-  wr.buf.Data = append(wr.buf.Data, wr.gen.signal(freq, length)...)
+  if wr.err != nil {
+    return
+  }
+
+  wr.position += length
+  end := int(math.Round(wr.position / 1000 * float64(wr.gen.sampleRate)))
+
+  if wr.shaper != nil {
+    wr.err = wr.out.writeSamples(wr.gen.modulate(wr.shaper.shape(freq, end-wr.samples)))
+  } else {
+    wr.err = wr.out.writeSamples(wr.gen.signal(freq, end-wr.samples))
+  }
+  wr.samples = end
+}
+
+// marks the end of the given transmitted line and thus checks for cancellation and reports the
+// progress of the encoding process
+func (wr *audioWriter) endLine(line int, lines int) {
+  if wr.err != nil {
+    return
+  }
+  if wr.ctx != nil {
+    if wr.err = wr.ctx.Err(); wr.err != nil {
+      return
+    }
+  }
+  if wr.progress != nil {
+    wr.progress(line, lines, milliseconds(wr.position))
+  }
 }
 
-// writes a boolean bit to the buffer (in the VIS code format)
+// flushes any remaining samples to the output and reports the first error which has been
+// encountered while writing (if any)
+func (wr *audioWriter) close() error {
+  if wr.err == nil && wr.shaper != nil {
+    wr.err = wr.out.writeSamples(wr.gen.modulate(wr.shaper.drain()))
+  }
+  if wr.err != nil {
+    return wr.err
+  }
+  return wr.out.flush()
+}
+
+// appends a sequence of fixed frequency segments to the output
+func (wr *audioWriter) writeTones(tones ...tone) {
+  for _, t := range tones {
+    wr.write(t.frequency, t.length)
+  }
+}
+
+// writes a boolean bit to the output (in the VIS code format)
 func (wr *audioWriter) writeBit(val bool) {
   if val {
-    wr.write(trueFrequency, bitLength)
+    wr.write(trueFrequency+wr.visOffset, bitLength)
   } else {
-    wr.write(falseFrequency, bitLength)
+    wr.write(falseFrequency+wr.visOffset, bitLength)
   }
 }
 
 func (wr *audioWriter) writeHeader() {
-  wr.write(headerFrequency, headerLength)
-  wr.write(headerPauseFrequency, headerPauseLength)
-  wr.write(headerFrequency, headerLength)
+  wr.writePreamble(calibrationHeader)
+}
+
+// writes a mode specific preamble in place of the standard calibration header
+func (wr *audioWriter) writePreamble(preamble []tone) {
+  wr.writeTones(preamble...)
 }
 
 func (wr *audioWriter) writeVis(val uint8) {
-  wr.write(headerVisFrequency, bitLength)
+  wr.write(headerVisFrequency+wr.visOffset, bitLength)
 
   p := parity(val)
   for i := 0; i < 7; i++ {
@@ -80,11 +234,83 @@ func (wr *audioWriter) writeVis(val uint8) {
   }
   wr.writeBit(p)
 
-  wr.write(headerVisFrequency, bitLength)
+  wr.write(headerVisFrequency+wr.visOffset, bitLength)
+}
+
+// writes the digital AVT header which follows the VIS code in AVT transmissions
+//
+// the header consists of a fixed number of 16 bit frames, each of which carries a byte which
+// identifies the mode (upper three bits) as well as the number of frames which remain until the
+// image starts (lower five bits, counting down to zero), followed by its inverse. This permits
+// receivers to verify the frame contents and synchronize to the start of the image even when
+// they lock on partway through the header
+func (wr *audioWriter) writeAVTHeader(val uint8) {
+  // all AVT modes differ within bits two to four of their VIS code
+  mode := ((val >> 2) & 0x7) << 5
+
+  for i := avtFrameCount - 1; i >= 0; i-- {
+    code := mode | uint8(i)
+    frame := uint16(code)<<8 | uint16(^code)
+
+    for bit := 15; bit >= 0; bit-- {
+      if frame>>uint(bit)&0x1 == 0x1 {
+        wr.write(avtTrueFrequency, avtBitLength)
+      } else {
+        wr.write(avtFalseFrequency, avtBitLength)
+      }
+    }
+  }
+}
+
+// writes a 16 bit extended VIS code (as introduced by MMSSTV)
+//
+// extended codes are transmitted least significant bit first and do not carry an additional
+// parity bit
+func (wr *audioWriter) writeExtendedVis(val uint16) {
+  wr.write(headerVisFrequency+wr.visOffset, bitLength)
+
+  for i := 0; i < 16; i++ {
+    wr.writeBit(val&0x1 == 0x1)
+    val >>= 1
+  }
+
+  wr.write(headerVisFrequency+wr.visOffset, bitLength)
 }
 
 func (wr *audioWriter) writeValue(val float64, length float64) {
-  wr.write(val*(whiteFrequency-blackFrequency)+blackFrequency, length)
+  wr.write(val*(wr.white-wr.black)+wr.black, length)
+}
+
+// writes a single line consisting of an arbitrary sequence of luminance and chrominance scans
+// each of which is followed by its respective separator tones (if any)
+func (wr *audioWriter) writeYUVLine(scans ...yuvScan) {
+  for _, scan := range scans {
+    for _, val := range scan.values {
+      wr.writeValue(float64(val)/255, scan.pulseLength)
+    }
+
+    wr.writeTones(scan.separator...)
+  }
+}
+
+// writes a pair of image lines starting at the given line as Y (even line), R-Y, B-Y and Y (odd
+// line) where both lines share their averaged chrominance values
+func (wr *audioWriter) writeYUVPair(img image.Image, y int, pulseLength float64) {
+  size := img.Bounds().Size()
+  y0 := make([]byte, size.X)
+  y1 := make([]byte, size.X)
+  u := make([]byte, size.X)
+  v := make([]byte, size.X)
+
+  for x := 0; x < size.X; x++ {
+    y0[x], y1[x], u[x], v[x] = convertYUVPair(img.At(x, y), img.At(x, y+1))
+  }
+
+  for _, values := range [][]byte{y0, v, u, y1} {
+    for x := 0; x < size.X; x++ {
+      wr.writeValue(float64(values[x])/255, pulseLength)
+    }
+  }
 }
 
 // computes the VIS parity
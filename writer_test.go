@@ -0,0 +1,109 @@
+/*
+ * Copyright 2018 Johannes Donath <johannesd@torchmind.com>
+ * and other copyright owners as documented in the project's IP log.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+package sstv
+
+import (
+  "github.com/go-audio/audio"
+  "image"
+  "math"
+  "testing"
+  "time"
+)
+
+// counts the samples which are passed to it without retaining them
+type countingSink struct {
+  samples int
+}
+
+func (s *countingSink) writeSamples(samples []float64) error {
+  s.samples += len(samples)
+  return nil
+}
+
+func (s *countingSink) flush() error {
+  return nil
+}
+
+// creates an encoder for one representative mode of each family
+func timingEncoders(t *testing.T, format *audio.Format) []Encoder {
+  constructors := []func() (Encoder, error){
+    func() (Encoder, error) { return NewMartin(Martin1, format) },
+    func() (Encoder, error) { return NewScottie(Scottie2, format) },
+    func() (Encoder, error) { return NewRobot(Robot36, format) },
+    func() (Encoder, error) { return NewRobot(Robot8BW, format) },
+    func() (Encoder, error) { return NewPD(PD50, format) },
+    func() (Encoder, error) { return NewPasokon(Pasokon3, format) },
+    func() (Encoder, error) { return NewWrasse(WrasseSC230, format) },
+    func() (Encoder, error) { return NewAVT(AVT24, format) },
+    func() (Encoder, error) { return NewMP(MP73, format) },
+    func() (Encoder, error) { return NewMR(MR73, format) },
+    func() (Encoder, error) { return NewMN(MN73, format) },
+    func() (Encoder, error) { return NewMC(MC110, format) },
+    func() (Encoder, error) { return NewFAX480(format) },
+  }
+
+  encoders := make([]Encoder, len(constructors))
+  for i, constructor := range constructors {
+    enc, err := constructor()
+    if err != nil {
+      t.Fatalf("failed to create encoder: %s", err)
+    }
+    encoders[i] = enc
+  }
+  return encoders
+}
+
+// converts a duration into the (fractional) number of samples at the given sample rate
+func durationToSamples(d time.Duration, sampleRate int) float64 {
+  return d.Seconds() * float64(sampleRate)
+}
+
+func TestWriterTiming(t *testing.T) {
+  for _, sampleRate := range []int{8000, 11025, 44100, 48000} {
+    format := &audio.Format{NumChannels: 1, SampleRate: sampleRate}
+
+    for _, enc := range timingEncoders(t, format) {
+      info := enc.Info()
+      out := &countingSink{}
+
+      line := 0
+      wr := newWriter(format, out, EncodeOptions{
+        Progress: func(l int, lines int, elapsed time.Duration) {
+          // every line must end within a single sample of its ideal position
+          remaining := time.Duration(lines-l-1) * info.LineDuration
+          expected := durationToSamples(info.Duration-remaining, sampleRate)
+          if math.Abs(float64(out.samples)-expected) > 1 {
+            t.Errorf("%s @ %d Hz: line %d ends at sample %d (expected %.1f)", info.Name, sampleRate, l, out.samples, expected)
+          }
+          line++
+        },
+      })
+
+      enc.(modeEncoder).encode(wr, image.NewRGBA(enc.Resolution()))
+      if err := wr.close(); err != nil {
+        t.Fatalf("%s @ %d Hz: %s", info.Name, sampleRate, err)
+      }
+
+      if line != info.Lines {
+        t.Errorf("%s @ %d Hz: encoded %d lines (expected %d)", info.Name, sampleRate, line, info.Lines)
+      }
+      if expected := durationToSamples(info.Duration, sampleRate); math.Abs(float64(out.samples)-expected) > 1 {
+        t.Errorf("%s @ %d Hz: encoded %d samples (expected %.1f)", info.Name, sampleRate, out.samples, expected)
+      }
+    }
+  }
+}